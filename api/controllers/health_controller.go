@@ -6,15 +6,23 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kevinfinalboss/Void/api/models"
+	"github.com/kevinfinalboss/Void/internal/bot"
 )
 
+// ShardReporter reports the health of the bot's gateway shards.
+type ShardReporter interface {
+	ShardStatus() []bot.ShardStatus
+}
+
 type HealthController struct {
 	startTime time.Time
+	shards    ShardReporter
 }
 
-func NewHealthController() *HealthController {
+func NewHealthController(shards ShardReporter) *HealthController {
 	return &HealthController{
 		startTime: time.Now(),
+		shards:    shards,
 	}
 }
 
@@ -23,3 +31,19 @@ func (hc *HealthController) CheckHealth(c *gin.Context) {
 	response := models.NewHealthResponse(uptime)
 	c.JSON(http.StatusOK, response)
 }
+
+// ShardStatus lists the state of every shard. It answers 503 while any shard
+// is not ready.
+func (hc *HealthController) ShardStatus(c *gin.Context) {
+	shards := hc.shards.ShardStatus()
+
+	status := http.StatusOK
+	for _, shard := range shards {
+		if shard.State != bot.ShardReady {
+			status = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	c.JSON(status, models.NewShardsResponse(shards))
+}
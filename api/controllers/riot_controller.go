@@ -6,24 +6,49 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/kevinfinalboss/Void/api/models"
 	"github.com/kevinfinalboss/Void/api/services"
+	"github.com/kevinfinalboss/Void/config"
 )
 
 type RiotController struct {
+	store       *config.Store
 	riotService *services.RiotService
 }
 
-func NewRiotController(apiKey string) *RiotController {
+func NewRiotController(store *config.Store) *RiotController {
 	return &RiotController{
-		riotService: services.NewRiotService(apiKey),
+		store:       store,
+		riotService: services.NewRiotService(store.Get().Riot.APIKey),
 	}
 }
 
+func (rc *RiotController) SetAPIKey(apiKey string) {
+	rc.riotService.SetAPIKey(apiKey)
+}
+
+// available answers 503 while the Riot feature is disabled, e.g. because
+// no API key is configured, and reports whether the request may proceed.
+func (rc *RiotController) available(c *gin.Context) bool {
+	if rc.store.Get().Enabled(config.FeatureRiot) {
+		return true
+	}
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": "riot integration is disabled",
+	})
+	return false
+}
+
 func (rc *RiotController) ServeRiotTxt(c *gin.Context) {
+	if !rc.available(c) {
+		return
+	}
 	c.Header("Content-Type", "text/plain")
 	c.String(http.StatusOK, "a66bc314-412d-4e19-9ab0-56008f94b90a")
 }
 
 func (rc *RiotController) GetChampionRotation(c *gin.Context) {
+	if !rc.available(c) {
+		return
+	}
 	region := c.DefaultQuery("region", "br1")
 
 	rotations, err := rc.riotService.GetChampionRotations(region)
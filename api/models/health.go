@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/kevinfinalboss/Void/internal/bot"
+)
 
 type HealthResponse struct {
 	Status    string `json:"status"`
@@ -22,3 +26,15 @@ func NewHealthResponse(uptime time.Duration) HealthResponse {
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 }
+
+type ShardsResponse struct {
+	Shards    []bot.ShardStatus `json:"shards"`
+	Timestamp string            `json:"timestamp"`
+}
+
+func NewShardsResponse(shards []bot.ShardStatus) ShardsResponse {
+	return ShardsResponse{
+		Shards:    shards,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
@@ -6,15 +6,24 @@ import (
 	"github.com/kevinfinalboss/Void/config"
 )
 
-func SetupRoutes(r gin.IRouter, cfg *config.Config) {
-	riotController := controllers.NewRiotController(cfg.Riot.APIKey)
-	healthController := controllers.NewHealthController()
+func SetupRoutes(r gin.IRouter, store *config.Store, shards controllers.ShardReporter) {
+	healthController := controllers.NewHealthController(shards)
 
-	r.GET("/riot.txt", riotController.ServeRiotTxt)
 	r.GET("/health", healthController.CheckHealth)
+	r.GET("/health/shards", healthController.ShardStatus)
 
 	r.Static("/assets/champions/icons", "./assets/champions/icons")
 
+	// The Riot routes are always registered, so a reload can enable them;
+	// while the feature is disabled they answer 503.
+	riotController := controllers.NewRiotController(store)
+	store.Subscribe(func(old, cfg *config.Config) {
+		if cfg.Riot.APIKey != old.Riot.APIKey {
+			riotController.SetAPIKey(cfg.Riot.APIKey)
+		}
+	})
+	r.GET("/riot.txt", riotController.ServeRiotTxt)
+
 	riot := r.Group("/riot")
 	{
 		riot.GET("/rotation", riotController.GetChampionRotation)
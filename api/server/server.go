@@ -6,17 +6,21 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kevinfinalboss/Void/api/controllers"
 	"github.com/kevinfinalboss/Void/api/routes"
 	"github.com/kevinfinalboss/Void/config"
 )
 
 type Server struct {
 	router     *gin.Engine
-	config     *config.Config
+	store      *config.Store
 	httpServer *http.Server
 }
 
-func NewServer(cfg *config.Config) *Server {
+// NewServer creates the HTTP server. The listen address and mode are read
+// once; the routes follow reloads of store.
+func NewServer(store *config.Store) *Server {
+	cfg := store.Get()
 	gin.SetMode(cfg.Server.Mode)
 	router := gin.New()
 	router.Use(gin.Recovery())
@@ -30,17 +34,17 @@ func NewServer(cfg *config.Config) *Server {
 
 	return &Server{
 		router:     router,
-		config:     cfg,
+		store:      store,
 		httpServer: httpServer,
 	}
 }
 
-func (s *Server) SetupRoutes() {
-	if s.config.Server.BasePath != "" {
-		group := s.router.Group(s.config.Server.BasePath)
-		routes.SetupRoutes(group, s.config)
+func (s *Server) SetupRoutes(shards controllers.ShardReporter) {
+	if basePath := s.store.Get().Server.BasePath; basePath != "" {
+		group := s.router.Group(basePath)
+		routes.SetupRoutes(group, s.store, shards)
 	} else {
-		routes.SetupRoutes(s.router, s.config)
+		routes.SetupRoutes(s.router, s.store, shards)
 	}
 }
 
@@ -49,6 +49,19 @@ func NewRiotService(apiKey string) *RiotService {
 	}
 }
 
+// SetAPIKey replaces the key used by the next requests.
+func (s *RiotService) SetAPIKey(apiKey string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.apiKey = apiKey
+}
+
+func (s *RiotService) key() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.apiKey
+}
+
 func (s *RiotService) GetLatestVersion() (string, error) {
 	if s.version != "" {
 		return s.version, nil
@@ -81,7 +94,11 @@ func (s *RiotService) GetChampionRotations(region string) (*ChampionInfo, error)
 		return nil, err
 	}
 
-	req.Header.Set("X-Riot-Token", s.apiKey)
+	apiKey := s.key()
+	if apiKey == "" {
+		return nil, fmt.Errorf("riot api key is not configured")
+	}
+	req.Header.Set("X-Riot-Token", apiKey)
 	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en;q=0.8")
 	req.Header.Set("Accept-Charset", "application/x-www-form-urlencoded; charset=UTF-8")
 
@@ -1,29 +1,52 @@
 package admin
 
 import (
+	"context"
 	"fmt"
-	"regexp"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/kevinfinalboss/Void/config"
-	"github.com/kevinfinalboss/Void/internal/database"
+	"github.com/kevinfinalboss/Void/internal/models"
 	"github.com/kevinfinalboss/Void/internal/registry"
 	"github.com/kevinfinalboss/Void/internal/types"
 )
 
-var (
-	db            *database.MongoDB
-	awaitingAudit sync.Map
+const (
+	configComponentTTL = 10 * time.Minute
+	configPrefixInput  = "prefix"
+	maxManagerRoles    = 10
 )
 
-func SetDatabase(mongodb *database.MongoDB) {
-	db = mongodb
+// The pages of the settings panel, in the order of the navigation buttons.
+const (
+	configPageGeneral     = "geral"
+	configPageAudit       = "audit"
+	configPagePermissions = "permissoes"
+)
+
+var configPages = []struct {
+	Name  string
+	Label string
+	Emoji string
+}{
+	{configPageGeneral, "Geral", "⚙️"},
+	{configPageAudit, "Auditoria", "📝"},
+	{configPagePermissions, "Permissões", "🛡️"},
+}
+
+var auditCategoryLabels = map[string]string{
+	models.AuditMessages:      "Mensagens editadas e apagadas",
+	models.AuditMembers:       "Entradas e saídas",
+	models.AuditBans:          "Banimentos",
+	models.AuditMemberUpdates: "Cargos e apelidos",
+	models.AuditChannels:      "Canais",
+	models.AuditVoice:         "Canais de voz",
 }
 
 func init() {
 	registry.RegisterCommand(ConfigCommand)
+	registry.RegisterComponent(ConfigComponent)
 }
 
 var ConfigCommand = &types.Command{
@@ -32,124 +55,336 @@ var ConfigCommand = &types.Command{
 	Category:    "Administração",
 	AdminOnly:   true,
 	Cooldown:    5 * time.Second,
-	Run: func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error {
-		embed := &discordgo.MessageEmbed{
-			Title:       "⚙️ Configurações do Servidor",
-			Description: "Selecione uma opção abaixo para configurar:",
-			Color:       0x2B2D31,
-			Timestamp:   time.Now().Format(time.RFC3339),
-			Footer: &discordgo.MessageEmbedFooter{
-				Text: "Devil • Configurações",
-			},
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		if inv.GuildID == "" {
+			return types.NewUserError("Este comando só pode ser usado em servidores.", nil)
 		}
 
-		components := []discordgo.MessageComponent{
-			discordgo.ActionsRow{
-				Components: []discordgo.MessageComponent{
-					discordgo.Button{
-						Label:    "Definir Canal de Audit",
-						Style:    discordgo.PrimaryButton,
-						CustomID: "btn_set_audit",
-						Emoji: &discordgo.ComponentEmoji{
-							Name: "📝",
-						},
-					},
-				},
-			},
+		panel, err := configPanel(inv, configPageGeneral)
+		if err != nil {
+			return err
 		}
-
-		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Embeds:     []*discordgo.MessageEmbed{embed},
-				Components: components,
-				Flags:      discordgo.MessageFlagsEphemeral,
-			},
-		})
+		panel.Flags = discordgo.MessageFlagsEphemeral
+		return inv.Responder.Respond(panel)
 	},
 }
 
-func HandleConfigButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.Type != discordgo.InteractionMessageComponent {
-		return
-	}
+// ConfigComponent handles the buttons, select menus and modals of the
+// settings panel. Every change is saved immediately and the panel is
+// redrawn with the stored values.
+var ConfigComponent = &types.Component{
+	Name: "config",
+	Run: func(ctx context.Context, inv *types.Invocation, id *types.ComponentID) error {
+		page := id.Get("page")
 
-	switch i.MessageComponentData().CustomID {
-	case "btn_set_audit":
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseUpdateMessage,
-			Data: &discordgo.InteractionResponseData{
-				Content:    "Por favor, mencione o canal que deseja definir como canal de audit (#canal):",
-				Components: []discordgo.MessageComponent{},
-				Flags:      discordgo.MessageFlagsEphemeral,
-			},
-		})
-		if err != nil {
-			fmt.Printf("Erro ao responder à interação do botão: %v\n", err)
-			return
-		}
-
-		userID := i.Member.User.ID
-		channelID := i.ChannelID
-		guildID := i.GuildID
-
-		awaitingAudit.Store(userID, true)
+		switch action := id.Get("action"); action {
+		case "page":
+			// Only switches the page rendered below.
 
-		handler := func(s *discordgo.Session, m *discordgo.MessageCreate) {
-			if m.Author.ID != userID || m.ChannelID != channelID {
-				return
+		case "prefix":
+			settings, err := inv.Settings()
+			if err != nil {
+				return types.NewUserError("Erro ao carregar as configurações.", err)
 			}
+			return inv.OpenModal(prefixModal(inv, settings.Prefix))
 
-			if _, ok := awaitingAudit.Load(userID); !ok {
-				return
+		case "prefix_submit":
+			prefix := strings.TrimSpace(types.ModalValue(inv.Interaction, configPrefixInput))
+			if err := validatePrefix(prefix); err != nil {
+				return err
+			}
+			if err := inv.DB.UpdateGuildSettings(inv.GuildID, "prefix", prefix); err != nil {
+				return types.NewUserError("Erro ao salvar o prefixo.", err)
 			}
 
-			awaitingAudit.Delete(userID)
+		case "prefix_reset":
+			if err := inv.DB.UpdateGuildSettings(inv.GuildID, "prefix", ""); err != nil {
+				return types.NewUserError("Erro ao restaurar o prefixo.", err)
+			}
 
-			re := regexp.MustCompile(`<#(\d+)>`)
-			matches := re.FindStringSubmatch(m.Content)
-			if len(matches) < 2 {
-				s.ChannelMessageSend(channelID, "❌ Formato inválido. Por favor, mencione o canal usando #.")
-				return
+		case "audit_channel":
+			values := inv.Interaction.MessageComponentData().Values
+			if len(values) == 0 {
+				break
+			}
+			if err := inv.DB.UpdateGuildSettings(inv.GuildID, "audit_log_channel", values[0]); err != nil {
+				return types.NewUserError("Erro ao salvar o canal de audit.", err)
 			}
 
-			targetChannelID := matches[1]
+		case "audit_off":
+			if err := inv.DB.UpdateGuildSettings(inv.GuildID, "audit_log_channel", ""); err != nil {
+				return types.NewUserError("Erro ao desativar o canal de audit.", err)
+			}
 
-			targetChannel, err := s.State.Channel(targetChannelID)
-			if err != nil || targetChannel.GuildID != guildID {
-				targetChannel, err = s.Channel(targetChannelID)
-				if err != nil || targetChannel.GuildID != guildID {
-					s.ChannelMessageSend(channelID, "❌ Canal não encontrado ou não pertence a este servidor. Por favor, mencione um canal válido usando #.")
-					return
+		case "audit_events":
+			// The panel stores what is turned off, so categories added later
+			// start enabled.
+			selected := inv.Interaction.MessageComponentData().Values
+			disabled := []string{}
+			for _, category := range models.AuditCategories {
+				if !containsString(selected, category) {
+					disabled = append(disabled, category)
 				}
 			}
-
-			if err := db.UpdateGuildSettings(guildID, "audit_log_channel", targetChannel.ID); err != nil {
-				s.ChannelMessageSend(channelID, "❌ Erro ao salvar o canal de audit.")
-				return
+			if err := inv.DB.UpdateGuildSettings(inv.GuildID, "audit_disabled", disabled); err != nil {
+				return types.NewUserError("Erro ao salvar os eventos de audit.", err)
 			}
 
-			embed := &discordgo.MessageEmbed{
-				Title:       "✅ Configuração Salva",
-				Description: fmt.Sprintf("Canal de audit definido como <#%s>", targetChannel.ID),
-				Color:       0x00FF00,
-				Timestamp:   time.Now().Format(time.RFC3339),
-				Footer: &discordgo.MessageEmbedFooter{
-					Text: "Devil • Configurações",
-				},
+		case "manager_roles":
+			// Manager roles grant access to the admin commands, so only
+			// members with the actual permissions may change them.
+			if !types.HasAdminPermission(inv.Interaction) {
+				return types.NewUserError("Apenas membros com a permissão de Administrador ou Gerenciar Servidor podem alterar os cargos de gerência.", nil)
+			}
+			roles := inv.Interaction.MessageComponentData().Values
+			if err := inv.DB.UpdateGuildSettings(inv.GuildID, "manager_roles", roles); err != nil {
+				return types.NewUserError("Erro ao salvar os cargos de gerência.", err)
 			}
 
-			s.ChannelMessageSendEmbed(channelID, embed)
+		default:
+			return fmt.Errorf("unknown config action %q", action)
+		}
+
+		panel, err := configPanel(inv, page)
+		if err != nil {
+			return err
 		}
+		return inv.UpdateMessage(panel)
+	},
+}
+
+func configID(inv *types.Invocation, page, action string) string {
+	return types.NewComponentID("config", inv.UserID(), configComponentTTL).
+		With("page", page).
+		With("action", action).
+		String()
+}
 
-		removeHandler := s.AddHandler(handler)
+// configPanel renders the given page of the settings panel with a preview
+// of the current settings.
+func configPanel(inv *types.Invocation, page string) (*discordgo.InteractionResponseData, error) {
+	settings, err := inv.Settings()
+	if err != nil {
+		return nil, types.NewUserError("Erro ao carregar as configurações.", err)
+	}
+
+	var (
+		fields     []*discordgo.MessageEmbedField
+		components []discordgo.MessageComponent
+	)
 
-		time.AfterFunc(time.Minute, func() {
-			removeHandler()
-			if _, ok := awaitingAudit.Load(userID); ok {
-				awaitingAudit.Delete(userID)
-				s.ChannelMessageSend(channelID, "❌ Tempo esgotado. Por favor, tente novamente.")
+	switch page {
+	case configPageAudit:
+		fields = []*discordgo.MessageEmbedField{
+			{
+				Name:  "Canal de audit",
+				Value: channelMention(settings.AuditLogChannel),
+			},
+			{
+				Name:  "Eventos registrados",
+				Value: auditSummary(settings),
+			},
+		}
+
+		channelSelect := discordgo.SelectMenu{
+			MenuType:     discordgo.ChannelSelectMenu,
+			CustomID:     configID(inv, page, "audit_channel"),
+			Placeholder:  "Selecione o canal de audit",
+			ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+		}
+		if settings.AuditLogChannel != "" {
+			channelSelect.DefaultValues = []discordgo.SelectMenuDefaultValue{
+				{ID: settings.AuditLogChannel, Type: discordgo.SelectMenuDefaultValueChannel},
 			}
+		}
+
+		minEvents := 0
+		eventSelect := discordgo.SelectMenu{
+			MenuType:    discordgo.StringSelectMenu,
+			CustomID:    configID(inv, page, "audit_events"),
+			Placeholder: "Selecione os eventos registrados",
+			MinValues:   &minEvents,
+			MaxValues:   len(models.AuditCategories),
+			Disabled:    settings.AuditLogChannel == "",
+		}
+		for _, category := range models.AuditCategories {
+			eventSelect.Options = append(eventSelect.Options, discordgo.SelectMenuOption{
+				Label:   auditCategoryLabels[category],
+				Value:   category,
+				Default: !containsString(settings.AuditDisabled, category),
+			})
+		}
+
+		components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{channelSelect}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{eventSelect}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Desativar audit",
+					Style:    discordgo.DangerButton,
+					CustomID: configID(inv, page, "audit_off"),
+					Disabled: settings.AuditLogChannel == "",
+				},
+			}},
+		}
+
+	case configPagePermissions:
+		fields = []*discordgo.MessageEmbedField{
+			{
+				Name:  "Cargos de gerência",
+				Value: roleMentions(settings.ManagerRoles),
+			},
+			{
+				Name:  "Sobre",
+				Value: "Membros com estes cargos podem usar os comandos de administração, além de quem tem Administrador ou Gerenciar Servidor.",
+			},
+		}
+
+		minRoles := 0
+		roleSelect := discordgo.SelectMenu{
+			MenuType:    discordgo.RoleSelectMenu,
+			CustomID:    configID(inv, page, "manager_roles"),
+			Placeholder: "Selecione os cargos de gerência",
+			MinValues:   &minRoles,
+			MaxValues:   maxManagerRoles,
+		}
+		for _, role := range settings.ManagerRoles {
+			roleSelect.DefaultValues = append(roleSelect.DefaultValues, discordgo.SelectMenuDefaultValue{
+				ID:   role,
+				Type: discordgo.SelectMenuDefaultValueRole,
+			})
+		}
+
+		components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{roleSelect}},
+		}
+
+	default:
+		page = configPageGeneral
+
+		prefix := fmt.Sprintf("`%s`", inv.Config.Discord.Prefix) + " (padrão)"
+		if settings.Prefix != "" {
+			prefix = fmt.Sprintf("`%s`", settings.Prefix)
+		}
+		fields = []*discordgo.MessageEmbedField{
+			{
+				Name:  "Prefixo dos comandos de texto",
+				Value: prefix,
+			},
+		}
+
+		components = []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Alterar prefixo",
+					Style:    discordgo.PrimaryButton,
+					CustomID: configID(inv, page, "prefix"),
+				},
+				discordgo.Button{
+					Label:    "Restaurar padrão",
+					Style:    discordgo.SecondaryButton,
+					CustomID: configID(inv, page, "prefix_reset"),
+					Disabled: settings.Prefix == "",
+				},
+			}},
+		}
+	}
+
+	title := ""
+	navigation := make([]discordgo.MessageComponent, 0, len(configPages))
+	for _, p := range configPages {
+		style := discordgo.SecondaryButton
+		if p.Name == page {
+			style = discordgo.PrimaryButton
+			title = p.Label
+		}
+		navigation = append(navigation, discordgo.Button{
+			Label:    p.Label,
+			Style:    style,
+			CustomID: configID(inv, p.Name, "page"),
+			Disabled: p.Name == page,
+			Emoji:    &discordgo.ComponentEmoji{Name: p.Emoji},
 		})
 	}
+	components = append(components, discordgo.ActionsRow{Components: navigation})
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "⚙️ Configurações do Servidor • " + title,
+		Description: "Use os menus e botões abaixo para alterar as configurações. As mudanças são salvas imediatamente.",
+		Color:       0x2B2D31,
+		Fields:      fields,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Devil • Configurações",
+		},
+	}
+
+	return &discordgo.InteractionResponseData{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	}, nil
+}
+
+func prefixModal(inv *types.Invocation, current string) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		CustomID: configID(inv, configPageGeneral, "prefix_submit"),
+		Title:    "Prefixo dos comandos de texto",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    configPrefixInput,
+					Label:       "Novo prefixo",
+					Style:       discordgo.TextInputShort,
+					Placeholder: inv.Config.Discord.Prefix,
+					Value:       current,
+					Required:    true,
+					MinLength:   1,
+					MaxLength:   5,
+				},
+			}},
+		},
+	}
+}
+
+func channelMention(id string) string {
+	if id == "" {
+		return "Não definido"
+	}
+	return fmt.Sprintf("<#%s>", id)
+}
+
+func roleMentions(ids []string) string {
+	if len(ids) == 0 {
+		return "Nenhum"
+	}
+	mentions := make([]string, 0, len(ids))
+	for _, id := range ids {
+		mentions = append(mentions, fmt.Sprintf("<@&%s>", id))
+	}
+	return strings.Join(mentions, ", ")
+}
+
+func auditSummary(settings models.GuildSettings) string {
+	if settings.AuditLogChannel == "" {
+		return "Defina um canal para ativar o audit"
+	}
+	var enabled []string
+	for _, category := range models.AuditCategories {
+		if settings.AuditEnabled(category) {
+			enabled = append(enabled, auditCategoryLabels[category])
+		}
+	}
+	if len(enabled) == 0 {
+		return "Nenhum"
+	}
+	return strings.Join(enabled, "\n")
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/registry"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+func init() {
+	registry.RegisterCommand(PrefixCommand)
+}
+
+type prefixArgs struct {
+	Prefix string `option:"prefixo" description:"O novo prefixo (até 5 caracteres, sem espaços)" required:"true" min:"1" max:"5"`
+}
+
+var PrefixCommand = &types.Command{
+	Name:        "prefix",
+	Description: "Define o prefixo dos comandos de texto neste servidor",
+	Category:    "Administração",
+	AdminOnly:   true,
+	AllowPrefix: true,
+	Cooldown:    5 * time.Second,
+	Args:        prefixArgs{},
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		if inv.GuildID == "" {
+			return types.NewUserError("Este comando só pode ser usado em servidores.", nil)
+		}
+
+		var args prefixArgs
+		if err := inv.Bind(&args); err != nil {
+			return err
+		}
+
+		prefix := args.Prefix
+		if err := validatePrefix(prefix); err != nil {
+			return err
+		}
+
+		if err := inv.DB.UpdateGuildSettings(inv.GuildID, "prefix", prefix); err != nil {
+			return types.NewUserError("Erro ao salvar o prefixo.", err)
+		}
+
+		embed := &discordgo.MessageEmbed{
+			Title:       "✅ Configuração Salva",
+			Description: fmt.Sprintf("Prefixo dos comandos de texto definido como `%s`", prefix),
+			Color:       0x00FF00,
+			Timestamp:   time.Now().Format(time.RFC3339),
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: "Devil • Configurações",
+			},
+		}
+
+		return inv.Responder.Respond(&discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		})
+	},
+}
+
+func validatePrefix(prefix string) error {
+	if prefix == "" || len([]rune(prefix)) > 5 {
+		return types.NewUserError("O prefixo deve ter entre 1 e 5 caracteres.", nil)
+	}
+	if strings.ContainsAny(prefix, " \t\n") {
+		return types.NewUserError("O prefixo não pode conter espaços.", nil)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package files
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -21,36 +22,26 @@ func init() {
 	registry.RegisterCommand(ConvertDocumentCommand)
 }
 
+type convertDocumentArgs struct {
+	File *discordgo.MessageAttachment `option:"arquivo" description:"O arquivo PDF ou DOCX para converter" required:"true"`
+}
+
 var ConvertDocumentCommand = &types.Command{
 	Name:        "convert-document",
 	Description: "Converte documentos entre PDF e DOCX",
 	Category:    "Utilidade",
 	Cooldown:    5 * time.Second,
-	Options: []*types.CommandOption{
-		{
-			Name:        "arquivo",
-			Description: "O arquivo PDF ou DOCX para converter",
-			Type:        discordgo.ApplicationCommandOptionAttachment,
-			Required:    true,
-		},
-	},
-	Run: func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error {
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		})
-		if err != nil {
-			return fmt.Errorf("falha ao enviar a resposta inicial: %v", err)
-		}
-
-		options := i.ApplicationCommandData().Options
-		if len(options) == 0 {
-			return respondWithError(s, i, "Nenhum arquivo foi fornecido.")
-		}
-
-		attachment := i.ApplicationCommandData().Resolved.Attachments[options[0].Value.(string)]
-		if attachment == nil {
-			return respondWithError(s, i, "Falha ao resolver o anexo.")
+	AllowPrefix: true,
+	Defer:       true,
+	Timeout:     3 * time.Minute,
+	Requires:    []string{config.FeatureConvertAPI},
+	Args:        convertDocumentArgs{},
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		var args convertDocumentArgs
+		if err := inv.Bind(&args); err != nil {
+			return err
 		}
+		attachment := args.File
 
 		var sourceFormat, targetFormat string
 
@@ -61,19 +52,19 @@ var ConvertDocumentCommand = &types.Command{
 			sourceFormat = "docx"
 			targetFormat = "pdf"
 		} else {
-			return respondWithError(s, i, "Por favor, forneça um arquivo PDF ou DOCX válido.")
+			return types.NewUserError("Por favor, forneça um arquivo PDF ou DOCX válido.", nil)
 		}
 
-		fileData, err := downloadFile(attachment.URL)
+		fileData, err := downloadFile(ctx, attachment.URL)
 		if err != nil {
-			return respondWithError(s, i, "Falha ao baixar o arquivo.")
+			return types.NewUserError("Falha ao baixar o arquivo.", err)
 		}
 
 		fileBuffer := bytes.NewReader(fileData)
 
-		convertedData, err := convertDocument(cfg.ConvertAPI.Secret, fileBuffer, sourceFormat, targetFormat)
+		convertedData, err := convertDocument(ctx, inv.Config.ConvertAPI.Secret, fileBuffer, sourceFormat, targetFormat)
 		if err != nil {
-			return respondWithError(s, i, fmt.Sprintf("Erro ao converter o arquivo: %v", err))
+			return types.NewUserError(fmt.Sprintf("Erro ao converter o arquivo: %v", err), err)
 		}
 
 		embed := &discordgo.MessageEmbed{
@@ -105,27 +96,23 @@ var ConvertDocumentCommand = &types.Command{
 
 		convertedFileName := fmt.Sprintf("convertido.%s", targetFormat)
 
-		var userID string
-		if i.Interaction.User != nil {
-			userID = i.Interaction.User.ID
-		} else if i.Interaction.Member != nil && i.Interaction.Member.User != nil {
-			userID = i.Interaction.Member.User.ID
-		} else {
-			return respondWithError(s, i, "Não foi possível identificar o usuário.")
+		userID := inv.UserID()
+		if userID == "" {
+			return types.NewUserError("Não foi possível identificar o usuário.", nil)
 		}
 
 		var channelID string
 
-		if i.GuildID == "" {
-			channelID = i.ChannelID
+		if inv.GuildID == "" {
+			channelID = inv.Interaction.ChannelID
 		} else {
-			dmChannel, err := s.UserChannelCreate(userID)
+			dmChannel, err := inv.Session.UserChannelCreate(userID, discordgo.WithContext(ctx))
 			if err != nil {
-				return respondWithError(s, i, "Não foi possível enviar mensagem direta para você. Verifique se suas DMs estão abertas.")
+				return types.NewUserError("Não foi possível enviar mensagem direta para você. Verifique se suas DMs estão abertas.", err)
 			}
 			channelID = dmChannel.ID
 
-			_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			err = inv.Responder.Edit(&discordgo.WebhookEdit{
 				Embeds: &[]*discordgo.MessageEmbed{
 					{
 						Title:       "Conversão de Documento",
@@ -143,7 +130,7 @@ var ConvertDocumentCommand = &types.Command{
 			}
 		}
 
-		_, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		_, err = inv.Session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
 			Embeds: []*discordgo.MessageEmbed{embed},
 			Files: []*discordgo.File{
 				{
@@ -151,7 +138,7 @@ var ConvertDocumentCommand = &types.Command{
 					Reader: bytes.NewReader(convertedData),
 				},
 			},
-		})
+		}, discordgo.WithContext(ctx))
 		if err != nil {
 			return fmt.Errorf("falha ao enviar o arquivo convertido: %v", err)
 		}
@@ -160,8 +147,13 @@ var ConvertDocumentCommand = &types.Command{
 	},
 }
 
-func downloadFile(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+func downloadFile(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -170,7 +162,7 @@ func downloadFile(url string) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
-func convertDocument(secret string, file io.Reader, sourceFormat, targetFormat string) ([]byte, error) {
+func convertDocument(ctx context.Context, secret string, file io.Reader, sourceFormat, targetFormat string) ([]byte, error) {
 	apiURL := fmt.Sprintf("https://v2.convertapi.com/convert/%s/to/%s", sourceFormat, targetFormat)
 
 	var requestBody bytes.Buffer
@@ -187,7 +179,7 @@ func convertDocument(secret string, file io.Reader, sourceFormat, targetFormat s
 
 	writer.Close()
 
-	req, err := http.NewRequest("POST", apiURL, &requestBody)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, &requestBody)
 	if err != nil {
 		return nil, err
 	}
@@ -245,20 +237,3 @@ func convertDocument(secret string, file io.Reader, sourceFormat, targetFormat s
 
 	return convertedData, nil
 }
-
-func respondWithError(s *discordgo.Session, i *discordgo.InteractionCreate, message string) error {
-	embed := &discordgo.MessageEmbed{
-		Title:       "❌ Erro",
-		Description: message,
-		Color:       0xff0000,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Devil • Conversão de Documentos",
-		},
-		Timestamp: time.Now().Format(time.RFC3339),
-	}
-
-	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{embed},
-	})
-	return err
-}
@@ -0,0 +1,76 @@
+package images
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/registry"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+func init() {
+	registry.RegisterCommand(BlurMessageImageCommand)
+	registry.RegisterCommand(WebpToGifMessageCommand)
+}
+
+var BlurMessageImageCommand = &types.Command{
+	Name:        "Desfocar imagem",
+	Category:    "Imagens",
+	Cooldown:    5 * time.Second,
+	Defer:       true,
+	Timeout:     time.Minute,
+	Requires:    []string{config.FeatureCloudinary},
+	CommandType: discordgo.MessageApplicationCommand,
+	RunMessage: func(ctx context.Context, inv *types.Invocation, target *discordgo.Message) error {
+		attachment := findAttachment(target, isImageAttachment)
+		if attachment == nil {
+			return types.NewUserError("A mensagem selecionada não possui nenhuma imagem anexada.", nil)
+		}
+
+		return blurImage(ctx, inv, attachment.URL, 50)
+	},
+}
+
+var WebpToGifMessageCommand = &types.Command{
+	Name:        "Converter WebP para GIF",
+	Category:    "Imagens",
+	Cooldown:    5 * time.Second,
+	Defer:       true,
+	Timeout:     time.Minute,
+	Requires:    []string{config.FeatureCloudinary},
+	CommandType: discordgo.MessageApplicationCommand,
+	RunMessage: func(ctx context.Context, inv *types.Invocation, target *discordgo.Message) error {
+		attachment := findAttachment(target, func(a *discordgo.MessageAttachment) bool {
+			return strings.EqualFold(filepath.Ext(a.Filename), ".webp") || a.ContentType == "image/webp"
+		})
+		if attachment == nil {
+			return types.NewUserError("A mensagem selecionada não possui nenhuma imagem WebP anexada.", nil)
+		}
+
+		return convertWebPToGif(ctx, inv, attachment.URL)
+	},
+}
+
+func findAttachment(m *discordgo.Message, match func(*discordgo.MessageAttachment) bool) *discordgo.MessageAttachment {
+	for _, a := range m.Attachments {
+		if match(a) {
+			return a
+		}
+	}
+	return nil
+}
+
+func isImageAttachment(a *discordgo.MessageAttachment) bool {
+	if strings.HasPrefix(a.ContentType, "image/") {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(a.Filename)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp":
+		return true
+	}
+	return false
+}
@@ -10,70 +10,47 @@ import (
 	cloudinary "github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/admin"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
-	"github.com/kevinfinalboss/Void/config"
-	"github.com/kevinfinalboss/Void/internal/registry"
 	"github.com/kevinfinalboss/Void/internal/types"
 )
 
-func init() {
-	registry.RegisterCommand(ImageInfoCommand)
+type imageInfoArgs struct {
+	Image *discordgo.MessageAttachment `option:"imagem" description:"A imagem para obter informações" required:"true"`
 }
 
 var ImageInfoCommand = &types.Command{
-	Name:        "image-info",
+	Name:        "info",
 	Description: "Obtém informações detalhadas sobre uma imagem enviada",
-	Category:    "Utilidade",
 	Cooldown:    5 * time.Second,
-	Options: []*types.CommandOption{
-		{
-			Name:        "imagem",
-			Description: "A imagem para obter informações",
-			Type:        discordgo.ApplicationCommandOptionAttachment,
-			Required:    true,
-		},
-	},
-	Run: func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error {
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		})
-		if err != nil {
-			return fmt.Errorf("falha ao enviar a resposta inicial: %v", err)
-		}
-
-		options := i.ApplicationCommandData().Options
-		if len(options) == 0 {
-			return respondWithError(s, i, "Nenhum arquivo foi fornecido.")
-		}
-
-		attachment := i.ApplicationCommandData().Resolved.Attachments[options[0].Value.(string)]
-		if attachment == nil {
-			return respondWithError(s, i, "Falha ao resolver o anexo.")
+	Defer:       true,
+	Args:        imageInfoArgs{},
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		var args imageInfoArgs
+		if err := inv.Bind(&args); err != nil {
+			return err
 		}
 
-		imageData, err := downloadFile(attachment.URL)
+		imageData, err := downloadFile(ctx, args.Image.URL)
 		if err != nil {
-			return respondWithError(s, i, "Falha ao baixar a imagem.")
+			return types.NewUserError("Falha ao baixar a imagem.", err)
 		}
 
 		cld, err := cloudinary.NewFromParams(
-			cfg.Cloudinary.CloudName,
-			cfg.Cloudinary.APIKey,
-			cfg.Cloudinary.APISecret,
+			inv.Config.Cloudinary.CloudName,
+			inv.Config.Cloudinary.APIKey,
+			inv.Config.Cloudinary.APISecret,
 		)
 		if err != nil {
-			return respondWithError(s, i, "Erro ao configurar o Cloudinary.")
+			return types.NewUserError("Erro ao configurar o Cloudinary.", err)
 		}
 
-		ctx := context.Background()
-
 		uploadResult, err := cld.Upload.Upload(ctx, bytes.NewReader(imageData), uploader.UploadParams{})
 		if err != nil {
-			return respondWithError(s, i, "Erro ao fazer upload da imagem para o Cloudinary.")
+			return types.NewUserError("Erro ao fazer upload da imagem para o Cloudinary.", err)
 		}
 
 		imageInfo, err := cld.Admin.Asset(ctx, admin.AssetParams{PublicID: uploadResult.PublicID})
 		if err != nil {
-			return respondWithError(s, i, "Erro ao obter informações da imagem.")
+			return types.NewUserError("Erro ao obter informações da imagem.", err)
 		}
 
 		embed := &discordgo.MessageEmbed{
@@ -121,7 +98,7 @@ var ImageInfoCommand = &types.Command{
 			Timestamp: time.Now().Format(time.RFC3339),
 		}
 
-		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		err = inv.Responder.Edit(&discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 		})
 
@@ -0,0 +1,28 @@
+package images
+
+import (
+	"time"
+
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/registry"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+func init() {
+	registry.RegisterCommand(ImageCommand)
+}
+
+var ImageCommand = &types.Command{
+	Name:        "image",
+	Description: "Ferramentas para editar, converter e inspecionar imagens",
+	Category:    "Imagens",
+	AllowPrefix: true,
+	Timeout:     time.Minute,
+	Requires:    []string{config.FeatureCloudinary},
+	Subcommands: []*types.Command{
+		ResizeImageCommand,
+		BlurImageCommand,
+		ImageInfoCommand,
+		WebpToGifCommand,
+	},
+}
@@ -9,94 +9,53 @@ import (
 	"github.com/bwmarrin/discordgo"
 	cloudinary "github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
-	"github.com/kevinfinalboss/Void/config"
-	"github.com/kevinfinalboss/Void/internal/registry"
 	"github.com/kevinfinalboss/Void/internal/types"
 )
 
-func init() {
-	registry.RegisterCommand(ResizeImageCommand)
+type resizeArgs struct {
+	Image  *discordgo.MessageAttachment `option:"imagem" description:"A imagem para redimensionar" required:"true"`
+	Width  int64                        `option:"largura" description:"A nova largura da imagem" required:"true" min:"1"`
+	Height int64                        `option:"altura" description:"A nova altura da imagem" required:"true" min:"1"`
 }
 
 var ResizeImageCommand = &types.Command{
-	Name:        "resize-image",
+	Name:        "resize",
 	Description: "Redimensiona uma imagem para a largura e altura especificadas",
-	Category:    "Utilidade",
 	Cooldown:    5 * time.Second,
-	Options: []*types.CommandOption{
-		{
-			Name:        "imagem",
-			Description: "A imagem para redimensionar",
-			Type:        discordgo.ApplicationCommandOptionAttachment,
-			Required:    true,
-		},
-		{
-			Name:        "largura",
-			Description: "A nova largura da imagem",
-			Type:        discordgo.ApplicationCommandOptionInteger,
-			Required:    true,
-		},
-		{
-			Name:        "altura",
-			Description: "A nova altura da imagem",
-			Type:        discordgo.ApplicationCommandOptionInteger,
-			Required:    true,
-		},
-	},
-	Run: func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error {
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{},
-		})
-		if err != nil {
-			return fmt.Errorf("falha ao enviar a resposta inicial: %v", err)
+	Defer:       true,
+	Args:        resizeArgs{},
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		var args resizeArgs
+		if err := inv.Bind(&args); err != nil {
+			return err
 		}
 
-		options := i.ApplicationCommandData().Options
-		if len(options) < 3 {
-			return respondWithError(s, i, "Parâmetros insuficientes.")
-		}
-
-		attachment := i.ApplicationCommandData().Resolved.Attachments[options[0].Value.(string)]
-		if attachment == nil {
-			return respondWithError(s, i, "Falha ao resolver o anexo.")
-		}
-
-		width := options[1].IntValue()
-		height := options[2].IntValue()
-
-		if width <= 0 || height <= 0 {
-			return respondWithError(s, i, "Largura e altura devem ser maiores que zero.")
-		}
-
-		imageData, err := downloadFile(attachment.URL)
+		imageData, err := downloadFile(ctx, args.Image.URL)
 		if err != nil {
-			return respondWithError(s, i, "Falha ao baixar a imagem.")
+			return types.NewUserError("Falha ao baixar a imagem.", err)
 		}
 
 		cld, err := cloudinary.NewFromParams(
-			cfg.Cloudinary.CloudName,
-			cfg.Cloudinary.APIKey,
-			cfg.Cloudinary.APISecret,
+			inv.Config.Cloudinary.CloudName,
+			inv.Config.Cloudinary.APIKey,
+			inv.Config.Cloudinary.APISecret,
 		)
 		if err != nil {
-			return respondWithError(s, i, "Erro ao configurar o Cloudinary.")
+			return types.NewUserError("Erro ao configurar o Cloudinary.", err)
 		}
 
-		ctx := context.Background()
-
-		transformation := fmt.Sprintf("c_scale,w_%d,h_%d", width, height)
+		transformation := fmt.Sprintf("c_scale,w_%d,h_%d", args.Width, args.Height)
 
 		uploadResult, err := cld.Upload.Upload(ctx, bytes.NewReader(imageData), uploader.UploadParams{
 			Transformation: transformation,
 		})
 		if err != nil {
-			return respondWithError(s, i, "Erro ao fazer upload da imagem para o Cloudinary.")
+			return types.NewUserError("Erro ao fazer upload da imagem para o Cloudinary.", err)
 		}
 
-		resizedImageData, err := downloadFile(uploadResult.SecureURL)
+		resizedImageData, err := downloadFile(ctx, uploadResult.SecureURL)
 		if err != nil {
-			return respondWithError(s, i, "Falha ao baixar a imagem redimensionada.")
+			return types.NewUserError("Falha ao baixar a imagem redimensionada.", err)
 		}
 
 		embed := &discordgo.MessageEmbed{
@@ -106,12 +65,12 @@ var ResizeImageCommand = &types.Command{
 			Fields: []*discordgo.MessageEmbedField{
 				{
 					Name:   "Nova Largura",
-					Value:  fmt.Sprintf("%d px", width),
+					Value:  fmt.Sprintf("%d px", args.Width),
 					Inline: true,
 				},
 				{
 					Name:   "Nova Altura",
-					Value:  fmt.Sprintf("%d px", height),
+					Value:  fmt.Sprintf("%d px", args.Height),
 					Inline: true,
 				},
 			},
@@ -121,7 +80,7 @@ var ResizeImageCommand = &types.Command{
 			Timestamp: time.Now().Format(time.RFC3339),
 		}
 
-		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		err = inv.Responder.Edit(&discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 			Files: []*discordgo.File{
 				{
@@ -2,6 +2,7 @@ package util
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"image"
@@ -14,7 +15,6 @@ import (
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/kevinfinalboss/Void/config"
 	"github.com/kevinfinalboss/Void/internal/registry"
 	"github.com/kevinfinalboss/Void/internal/types"
 	"github.com/nfnt/resize"
@@ -24,76 +24,27 @@ func init() {
 	registry.RegisterCommand(AddEmojiCommand)
 }
 
+type addEmojiArgs struct {
+	Name  string                       `option:"nome" description:"Nome para o emoji" required:"true" min:"2" max:"32"`
+	Image *discordgo.MessageAttachment `option:"imagem" description:"Imagem para usar como emoji (suporta PNG, JPG, GIF e WEBP)" required:"true"`
+}
+
 var AddEmojiCommand = &types.Command{
 	Name:        "addemoji",
 	Description: "Adiciona uma imagem como emoji no servidor",
 	Category:    "Utilidade",
 	Cooldown:    5 * time.Second,
+	AllowPrefix: true,
+	Defer:       true,
 	AdminOnly:   true,
-	Options: []*types.CommandOption{
-		{
-			Name:        "nome",
-			Description: "Nome para o emoji",
-			Type:        discordgo.ApplicationCommandOptionString,
-			Required:    true,
-		},
-		{
-			Name:        "imagem",
-			Description: "Imagem para usar como emoji (suporta PNG, JPG, GIF e WEBP)",
-			Type:        discordgo.ApplicationCommandOptionAttachment,
-			Required:    true,
-		},
-	},
-	Run: func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error {
-		// Resposta inicial diferida
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		})
-		if err != nil {
+	Args:        addEmojiArgs{},
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		var args addEmojiArgs
+		if err := inv.Bind(&args); err != nil {
 			return err
 		}
-
-		// Verifica permissões do usuário
-		member, err := s.GuildMember(i.GuildID, i.Member.User.ID)
-		if err != nil {
-			return respondWithError(s, i, "Erro ao verificar permissões do usuário")
-		}
-
-		hasPermission := false
-		for _, roleID := range member.Roles {
-			role, err := s.State.Role(i.GuildID, roleID)
-			if err != nil {
-				continue
-			}
-			if role.Permissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator {
-				hasPermission = true
-				break
-			}
-		}
-
-		if !hasPermission {
-			return respondWithError(s, i, "Você precisa ser administrador para usar este comando")
-		}
-
-		// Obtém os dados do comando
-		data := i.ApplicationCommandData()
-		emojiName := strings.ToLower(data.Options[0].StringValue())
-
-		// Obtém o attachment
-		var attachmentID string
-		for _, option := range data.Options {
-			if option.Name == "imagem" {
-				attachmentID = option.Value.(string)
-				break
-			}
-		}
-
-		attachment := data.Resolved.Attachments[attachmentID]
-
-		// Verifica se o nome do emoji é válido
-		if len(emojiName) < 2 || len(emojiName) > 32 {
-			return respondWithError(s, i, "O nome do emoji deve ter entre 2 e 32 caracteres")
-		}
+		emojiName := strings.ToLower(args.Name)
+		attachment := args.Image
 
 		// Verifica se a imagem é muito grande
 		var maxSize int64
@@ -104,27 +55,31 @@ var AddEmojiCommand = &types.Command{
 		}
 
 		if int64(attachment.Size) > maxSize {
-			return respondWithError(s, i, fmt.Sprintf("A imagem é muito grande. O limite é %dKB", maxSize/1000))
+			return types.NewUserError(fmt.Sprintf("A imagem é muito grande. O limite é %dKB", maxSize/1000), nil)
 		}
 
 		// Baixa a imagem
-		resp, err := http.Get(attachment.URL)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, attachment.URL, nil)
+		if err != nil {
+			return types.NewUserError("Erro ao baixar a imagem", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
-			return respondWithError(s, i, "Erro ao baixar a imagem")
+			return types.NewUserError("Erro ao baixar a imagem", err)
 		}
 		defer resp.Body.Close()
 
 		// Lê todos os bytes da imagem
 		imageBytes, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return respondWithError(s, i, "Erro ao ler a imagem")
+			return types.NewUserError("Erro ao ler a imagem", err)
 		}
 
 		// Se for uma imagem estática, redimensiona
 		if !isAnimated(attachment.Filename) {
 			img, format, err := image.Decode(bytes.NewReader(imageBytes))
 			if err != nil {
-				return respondWithError(s, i, "Formato de imagem inválido")
+				return types.NewUserError("Formato de imagem inválido", err)
 			}
 
 			// Redimensiona a imagem para 128x128
@@ -137,21 +92,21 @@ var AddEmojiCommand = &types.Command{
 			case "png":
 				err = png.Encode(&buf, resizedImg)
 			default:
-				return respondWithError(s, i, "Formato de imagem não suportado para imagens estáticas. Use PNG ou JPG")
+				return types.NewUserError("Formato de imagem não suportado para imagens estáticas. Use PNG ou JPG", nil)
 			}
 			if err != nil {
-				return respondWithError(s, i, "Erro ao processar a imagem")
+				return types.NewUserError("Erro ao processar a imagem", err)
 			}
 			imageBytes = buf.Bytes()
 		}
 
 		// Cria o emoji
-		emoji, err := s.GuildEmojiCreate(i.GuildID, &discordgo.EmojiParams{
+		emoji, err := inv.Session.GuildEmojiCreate(inv.GuildID, &discordgo.EmojiParams{
 			Name:  emojiName,
 			Image: fmt.Sprintf("data:image/%s;base64,%s", getImageFormat(attachment.Filename), base64.StdEncoding.EncodeToString(imageBytes)),
 		})
 		if err != nil {
-			return respondWithError(s, i, "Erro ao criar o emoji. Verifique se o bot tem permissões adequadas e se há espaço disponível para novos emojis")
+			return types.NewUserError("Erro ao criar o emoji. Verifique se o bot tem permissões adequadas e se há espaço disponível para novos emojis", err)
 		}
 
 		// Responde com sucesso
@@ -167,7 +122,7 @@ var AddEmojiCommand = &types.Command{
 			Timestamp: time.Now().Format(time.RFC3339),
 		}
 
-		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		err = inv.Responder.Edit(&discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 		})
 
@@ -200,20 +155,3 @@ func getEmojiType(filename string) string {
 	}
 	return "Emoji Estático"
 }
-
-func respondWithError(s *discordgo.Session, i *discordgo.InteractionCreate, message string) error {
-	embed := &discordgo.MessageEmbed{
-		Title:       "Erro na Geração de Resposta",
-		Description: message,
-		Color:       0xFF0000,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Devil - IA • Erro",
-		},
-		Timestamp: time.Now().Format(time.RFC3339),
-	}
-
-	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{embed},
-	})
-	return err
-}
@@ -0,0 +1,512 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/registry"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+const (
+	helpCommandsPerPage = 8
+	helpComponentTTL    = 10 * time.Minute
+)
+
+func init() {
+	registry.RegisterCommand(HelpCommand)
+	registry.RegisterComponent(HelpComponent)
+}
+
+type helpArgs struct {
+	Command string `option:"comando" description:"Mostra os detalhes de um comando" autocomplete:"true"`
+	Page    int64  `option:"pagina" description:"Página da lista de comandos" min:"1"`
+}
+
+var HelpCommand = &types.Command{
+	Name:         "help",
+	Description:  "Lista os comandos disponíveis e mostra como usá-los",
+	Category:     "Utilidade",
+	Cooldown:     3 * time.Second,
+	AllowPrefix:  true,
+	Args:         helpArgs{},
+	AutoComplete: helpAutoComplete,
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		var args helpArgs
+		if err := inv.Bind(&args); err != nil {
+			return err
+		}
+
+		entries := visibleHelpEntries(inv)
+
+		var (
+			embed      *discordgo.MessageEmbed
+			components []discordgo.MessageComponent
+		)
+		if args.Command != "" {
+			name := normalizeHelpName(args.Command)
+			if entry := findHelpEntry(entries, name); entry != nil {
+				embed = helpCommandEmbed(inv, entry)
+			} else if children := helpChildren(entries, name); len(children) > 0 {
+				embed = helpPageEmbed([]helpPage{{Category: "/" + name, Entries: children}}, 1)
+			} else {
+				return types.NewUserError(fmt.Sprintf("Comando `%s` não encontrado.", args.Command), nil)
+			}
+		} else {
+			pages := helpPages(entries)
+			page := int(args.Page)
+			if page == 0 {
+				page = 1
+			}
+			if page > len(pages) {
+				return types.NewUserError(fmt.Sprintf("Página inválida. Existem %d páginas.", len(pages)), nil)
+			}
+			embed = helpPageEmbed(pages, page)
+			components = helpPageButtons(inv, page, len(pages))
+		}
+
+		return inv.Responder.Respond(&discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		})
+	},
+}
+
+// HelpComponent flips the pages of the command list.
+var HelpComponent = &types.Component{
+	Name: "help",
+	Run: func(ctx context.Context, inv *types.Invocation, id *types.ComponentID) error {
+		page, err := id.Int("page")
+		if err != nil {
+			return fmt.Errorf("invalid help page: %v", err)
+		}
+
+		pages := helpPages(visibleHelpEntries(inv))
+		if page < 1 {
+			page = 1
+		}
+		if page > len(pages) {
+			page = len(pages)
+		}
+
+		return inv.UpdateMessage(&discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{helpPageEmbed(pages, page)},
+			Components: helpPageButtons(inv, page, len(pages)),
+		})
+	},
+}
+
+// helpEntry is a runnable command as shown by /help, with the category and
+// restrictions it inherits from its parents already applied.
+type helpEntry struct {
+	Path        string
+	Command     *types.Command
+	Category    string
+	Cooldown    time.Duration
+	DevOnly     bool
+	AdminOnly   bool
+	AllowPrefix bool
+}
+
+func (e *helpEntry) contextMenu() bool {
+	t := e.Command.CommandType
+	return t == discordgo.UserApplicationCommand || t == discordgo.MessageApplicationCommand
+}
+
+// visibleHelpEntries lists the runnable commands the invoking user is
+// allowed to use, sorted by category and path.
+func visibleHelpEntries(inv *types.Invocation) []*helpEntry {
+	var entries []*helpEntry
+	var collect func(cmd *types.Command, parent *helpEntry)
+	collect = func(cmd *types.Command, parent *helpEntry) {
+		if !inv.Config.Enabled(cmd.Requires...) {
+			return
+		}
+		entry := &helpEntry{
+			Path:        cmd.Name,
+			Command:     cmd,
+			Category:    cmd.Category,
+			Cooldown:    cmd.Cooldown,
+			DevOnly:     cmd.DevOnly,
+			AdminOnly:   cmd.AdminOnly,
+			AllowPrefix: cmd.AllowPrefix,
+		}
+		if parent != nil {
+			entry.Path = parent.Path + " " + cmd.Name
+			entry.DevOnly = entry.DevOnly || parent.DevOnly
+			entry.AdminOnly = entry.AdminOnly || parent.AdminOnly
+			entry.AllowPrefix = entry.AllowPrefix || parent.AllowPrefix
+			if entry.Category == "" {
+				entry.Category = parent.Category
+			}
+			if entry.Cooldown == 0 {
+				entry.Cooldown = parent.Cooldown
+			}
+		}
+		if len(cmd.Subcommands) == 0 {
+			if entry.Category == "" {
+				entry.Category = "Outros"
+			}
+			entries = append(entries, entry)
+			return
+		}
+		for _, sub := range cmd.Subcommands {
+			collect(sub, entry)
+		}
+	}
+	for _, cmd := range registry.Commands {
+		collect(cmd, nil)
+	}
+
+	admin := inv.IsAdmin()
+	visible := entries[:0]
+	for _, entry := range entries {
+		if entry.DevOnly && !types.IsDeveloper(inv.Config, inv.UserID()) {
+			continue
+		}
+		if entry.AdminOnly && !admin {
+			continue
+		}
+		visible = append(visible, entry)
+	}
+
+	sort.Slice(visible, func(a, b int) bool {
+		if visible[a].Category != visible[b].Category {
+			return visible[a].Category < visible[b].Category
+		}
+		return visible[a].Path < visible[b].Path
+	})
+	return visible
+}
+
+func normalizeHelpName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(name), "/")), " "))
+}
+
+func findHelpEntry(entries []*helpEntry, name string) *helpEntry {
+	for _, entry := range entries {
+		if strings.ToLower(entry.Path) == name {
+			return entry
+		}
+	}
+	return nil
+}
+
+// helpChildren returns the subcommands of a command or group with
+// subcommands, which has no entry of its own.
+func helpChildren(entries []*helpEntry, name string) []*helpEntry {
+	var children []*helpEntry
+	for _, entry := range entries {
+		if strings.HasPrefix(strings.ToLower(entry.Path), name+" ") {
+			children = append(children, entry)
+		}
+	}
+	return children
+}
+
+type helpPage struct {
+	Category string
+	Entries  []*helpEntry
+}
+
+// helpPages groups the entries by category, splitting categories that do
+// not fit in a single page.
+func helpPages(entries []*helpEntry) []helpPage {
+	var pages []helpPage
+	for _, entry := range entries {
+		category := entry.Category
+		if len(pages) == 0 || pages[len(pages)-1].Category != category || len(pages[len(pages)-1].Entries) == helpCommandsPerPage {
+			pages = append(pages, helpPage{Category: category})
+		}
+		last := &pages[len(pages)-1]
+		last.Entries = append(last.Entries, entry)
+	}
+	if len(pages) == 0 {
+		pages = append(pages, helpPage{Category: "Comandos"})
+	}
+	return pages
+}
+
+func helpPageEmbed(pages []helpPage, page int) *discordgo.MessageEmbed {
+	current := pages[page-1]
+
+	categories := make([]string, 0)
+	for _, p := range pages {
+		if len(categories) == 0 || categories[len(categories)-1] != p.Category {
+			categories = append(categories, p.Category)
+		}
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0, len(current.Entries))
+	for _, entry := range current.Entries {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  helpDisplayName(entry),
+			Value: strings.Join(append([]string{helpDescription(entry)}, helpBadges(entry)...), "\n"),
+		})
+	}
+	if len(fields) == 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "Nenhum comando disponível",
+			Value: "Você não tem acesso a nenhum comando neste servidor.",
+		})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("📚 Ajuda • %s", current.Category),
+		Description: fmt.Sprintf("Categorias: %s\nUse `/help comando:<nome>` para ver os detalhes de um comando.", strings.Join(categories, ", ")),
+		Color:       0x2B2D31,
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Devil • Ajuda • Página %d/%d", page, len(pages)),
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+func helpPageButtons(inv *types.Invocation, page, total int) []discordgo.MessageComponent {
+	if total <= 1 {
+		return nil
+	}
+
+	button := func(label string, target int, disabled bool) discordgo.Button {
+		return discordgo.Button{
+			Label:    label,
+			Style:    discordgo.SecondaryButton,
+			Disabled: disabled,
+			CustomID: types.NewComponentID("help", inv.UserID(), helpComponentTTL).
+				With("page", strconv.Itoa(target)).
+				String(),
+		}
+	}
+
+	// The disabled buttons still need distinct CustomIDs within the row.
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				button("◀ Anterior", page-1, page == 1),
+				button("Próxima ▶", page+1, page == total),
+			},
+		},
+	}
+}
+
+func helpCommandEmbed(inv *types.Invocation, entry *helpEntry) *discordgo.MessageEmbed {
+	cmd := entry.Command
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:  "Uso",
+			Value: "`" + helpUsage("/", entry) + "`",
+		},
+	}
+
+	if len(cmd.Options) > 0 {
+		lines := make([]string, 0, len(cmd.Options))
+		for _, opt := range cmd.Options {
+			lines = append(lines, helpOptionLine(opt))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "Opções",
+			Value: strings.Join(lines, "\n"),
+		})
+	}
+
+	cooldown := "Nenhum"
+	if entry.Cooldown > 0 {
+		cooldown = entry.Cooldown.String()
+	}
+	fields = append(fields,
+		&discordgo.MessageEmbedField{
+			Name:   "Cooldown",
+			Value:  cooldown,
+			Inline: true,
+		},
+		&discordgo.MessageEmbedField{
+			Name:   "Permissão",
+			Value:  helpPermission(entry),
+			Inline: true,
+		},
+	)
+
+	if entry.AllowPrefix && !entry.contextMenu() {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Prefixo",
+			Value:  "`" + helpUsage(inv.Prefix(), entry) + "`",
+			Inline: true,
+		})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "📖 " + helpDisplayName(entry),
+		Description: helpDescription(entry),
+		Color:       0x2B2D31,
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Devil • Ajuda • " + entry.Category,
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+func helpDisplayName(entry *helpEntry) string {
+	switch entry.Command.CommandType {
+	case discordgo.UserApplicationCommand:
+		return entry.Path + " (menu de usuário)"
+	case discordgo.MessageApplicationCommand:
+		return entry.Path + " (menu de mensagem)"
+	}
+	return "/" + entry.Path
+}
+
+func helpDescription(entry *helpEntry) string {
+	if entry.Command.Description != "" {
+		return entry.Command.Description
+	}
+	switch entry.Command.CommandType {
+	case discordgo.UserApplicationCommand:
+		return "Clique com o botão direito em um usuário e escolha Apps."
+	case discordgo.MessageApplicationCommand:
+		return "Clique com o botão direito em uma mensagem e escolha Apps."
+	}
+	return "Sem descrição."
+}
+
+func helpBadges(entry *helpEntry) []string {
+	var badges []string
+	if entry.Cooldown > 0 {
+		badges = append(badges, "⏱️ "+entry.Cooldown.String())
+	}
+	if entry.DevOnly {
+		badges = append(badges, "🛠️ Desenvolvedores")
+	}
+	if entry.AdminOnly {
+		badges = append(badges, "🔒 Administração")
+	}
+	if entry.AllowPrefix && !entry.contextMenu() {
+		badges = append(badges, "💬 Prefixo")
+	}
+	if len(badges) == 0 {
+		return nil
+	}
+	return []string{strings.Join(badges, " • ")}
+}
+
+func helpPermission(entry *helpEntry) string {
+	switch {
+	case entry.DevOnly:
+		return "Desenvolvedores do bot"
+	case entry.AdminOnly:
+		return "Administrador, Gerenciar Servidor ou cargo de gerência"
+	}
+	return "Todos"
+}
+
+func helpUsage(prefix string, entry *helpEntry) string {
+	if entry.contextMenu() {
+		return "Apps › " + entry.Path
+	}
+
+	parts := []string{prefix + entry.Path}
+	for _, opt := range entry.Command.Options {
+		if opt.Required {
+			parts = append(parts, fmt.Sprintf("%s:<%s>", opt.Name, helpOptionType(opt.Type)))
+		} else {
+			parts = append(parts, fmt.Sprintf("[%s:<%s>]", opt.Name, helpOptionType(opt.Type)))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func helpOptionLine(opt *types.CommandOption) string {
+	details := []string{helpOptionType(opt.Type)}
+	if opt.Required {
+		details = append(details, "obrigatório")
+	}
+	switch {
+	case opt.MinValue != nil && opt.MaxValue != 0:
+		details = append(details, fmt.Sprintf("%v–%v", *opt.MinValue, opt.MaxValue))
+	case opt.MinValue != nil:
+		details = append(details, fmt.Sprintf("mín. %v", *opt.MinValue))
+	case opt.MaxValue != 0:
+		details = append(details, fmt.Sprintf("máx. %v", opt.MaxValue))
+	}
+	switch {
+	case opt.MinLength != nil && opt.MaxLength != 0:
+		details = append(details, fmt.Sprintf("%d–%d caracteres", *opt.MinLength, opt.MaxLength))
+	case opt.MaxLength != 0:
+		details = append(details, fmt.Sprintf("até %d caracteres", opt.MaxLength))
+	}
+	if len(opt.Choices) > 0 {
+		values := make([]string, 0, len(opt.Choices))
+		for _, c := range opt.Choices {
+			values = append(values, fmt.Sprint(c.Value))
+		}
+		details = append(details, strings.Join(values, ", "))
+	}
+
+	line := fmt.Sprintf("`%s` (%s)", opt.Name, strings.Join(details, ", "))
+	if opt.Description != "" {
+		line += " — " + opt.Description
+	}
+	return line
+}
+
+func helpOptionType(t discordgo.ApplicationCommandOptionType) string {
+	switch t {
+	case discordgo.ApplicationCommandOptionString:
+		return "texto"
+	case discordgo.ApplicationCommandOptionInteger:
+		return "inteiro"
+	case discordgo.ApplicationCommandOptionNumber:
+		return "número"
+	case discordgo.ApplicationCommandOptionBoolean:
+		return "sim/não"
+	case discordgo.ApplicationCommandOptionUser:
+		return "usuário"
+	case discordgo.ApplicationCommandOptionChannel:
+		return "canal"
+	case discordgo.ApplicationCommandOptionRole:
+		return "cargo"
+	case discordgo.ApplicationCommandOptionMentionable:
+		return "menção"
+	case discordgo.ApplicationCommandOptionAttachment:
+		return "anexo"
+	}
+	return "valor"
+}
+
+func helpAutoComplete(ctx context.Context, inv *types.Invocation) ([]*discordgo.ApplicationCommandOptionChoice, error) {
+	query := ""
+	if focused := types.FocusedOption(inv.Interaction); focused != nil {
+		query = strings.ToLower(strings.TrimPrefix(focused.StringValue(), "/"))
+	}
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, 25)
+	for _, entry := range visibleHelpEntries(inv) {
+		if !strings.Contains(strings.ToLower(entry.Path), query) {
+			continue
+		}
+
+		name := helpDisplayName(entry)
+		if entry.Command.Description != "" {
+			name += " — " + entry.Command.Description
+		}
+		if len([]rune(name)) > 100 {
+			name = string([]rune(name)[:97]) + "..."
+		}
+
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: entry.Path,
+		})
+		if len(choices) == 25 {
+			break
+		}
+	}
+	return choices, nil
+}
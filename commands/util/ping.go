@@ -1,12 +1,12 @@
 package util
 
 import (
+	"context"
 	"fmt"
 	"runtime"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/kevinfinalboss/Void/config"
 	"github.com/kevinfinalboss/Void/internal/registry"
 	"github.com/kevinfinalboss/Void/internal/types"
 )
@@ -15,50 +15,35 @@ func init() {
 	registry.RegisterCommand(PingCommand)
 }
 
+type pingArgs struct {
+	Type string `option:"tipo" description:"Tipo de informação para exibir (basico, detalhado, sistema)" choices:"Básico=basico;Detalhado=detalhado;Sistema=sistema"`
+}
+
 var PingCommand = &types.Command{
 	Name:        "ping",
 	Description: "Responde com informações de latência e status do bot",
 	Category:    "Utilidade",
 	Cooldown:    5 * time.Second,
-	Options: []*types.CommandOption{
-		{
-			Name:        "tipo",
-			Description: "Tipo de informação para exibir (basico, detalhado, sistema)",
-			Type:        discordgo.ApplicationCommandOptionString,
-			Required:    false,
-			Choices: []*discordgo.ApplicationCommandOptionChoice{
-				{
-					Name:  "Básico",
-					Value: "basico",
-				},
-				{
-					Name:  "Detalhado",
-					Value: "detalhado",
-				},
-				{
-					Name:  "Sistema",
-					Value: "sistema",
-				},
-			},
-		},
-	},
-	Run: func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error {
+	AllowPrefix: true,
+	Args:        pingArgs{},
+	Run: func(ctx context.Context, inv *types.Invocation) error {
 		start := time.Now()
 
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		})
+		err := inv.Responder.Defer(false)
 		if err != nil {
 			return err
 		}
 
-		options := i.ApplicationCommandData().Options
-		checkType := "basico"
-		if len(options) > 0 && options[0].Name == "tipo" {
-			checkType = options[0].StringValue()
+		var args pingArgs
+		if err := inv.Bind(&args); err != nil {
+			return err
+		}
+		checkType := args.Type
+		if checkType == "" {
+			checkType = "basico"
 		}
 
-		latency := s.HeartbeatLatency()
+		latency := inv.Session.HeartbeatLatency()
 		restLatency := time.Since(start)
 
 		embed := &discordgo.MessageEmbed{
@@ -87,7 +72,8 @@ var PingCommand = &types.Command{
 			}
 
 		case "detalhado":
-			uptime := time.Since(cfg.BotStartTime)
+			uptime := time.Since(inv.Config.BotStartTime)
+			shardID, shardCount := inv.Shard()
 			embed.Fields = []*discordgo.MessageEmbedField{
 				{
 					Name:   "Latência do Gateway",
@@ -105,17 +91,28 @@ var PingCommand = &types.Command{
 					Inline: true,
 				},
 				{
-					Name:   "Shard ID",
-					Value:  fmt.Sprintf("`%d`", s.ShardID),
+					Name:   "Shard",
+					Value:  fmt.Sprintf("`%d/%d`", shardID, shardCount),
 					Inline: true,
 				},
 				{
-					Name:   "Guildas Conectadas",
-					Value:  fmt.Sprintf("`%d`", len(s.State.Guilds)),
+					Name:   "Guildas nesta Shard",
+					Value:  fmt.Sprintf("`%d`", inv.ShardGuilds()),
 					Inline: true,
 				},
 			}
 
+			total := "`indisponível`"
+			if stats, err := inv.Cluster.Stats(ctx); err == nil {
+				total = fmt.Sprintf("`%d` em %d shards e %d processo(s)", stats.Guilds, stats.Shards, stats.Processes)
+			} else {
+				inv.Logger.Error("Failed to query cluster stats", "error", err)
+			}
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:  "Guildas no Total",
+				Value: total,
+			})
+
 		case "sistema":
 			var m runtime.MemStats
 			runtime.ReadMemStats(&m)
@@ -150,7 +147,7 @@ var PingCommand = &types.Command{
 			embed.Description = "Tipo inválido fornecido. Por favor, escolha 'basico', 'detalhado' ou 'sistema'."
 		}
 
-		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		err = inv.Responder.Edit(&discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{embed},
 		})
 
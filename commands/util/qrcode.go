@@ -2,11 +2,11 @@ package util
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/kevinfinalboss/Void/config"
 	"github.com/kevinfinalboss/Void/internal/registry"
 	"github.com/kevinfinalboss/Void/internal/types"
 	"github.com/skip2/go-qrcode"
@@ -16,79 +16,27 @@ func init() {
 	registry.RegisterCommand(QRCodeCommand)
 }
 
+type qrCodeArgs struct {
+	Link string `option:"link" description:"O link para gerar o QR Code" required:"true" min:"1"`
+}
+
 var QRCodeCommand = &types.Command{
 	Name:        "qrcode",
 	Description: "Gera um QR Code a partir de um link fornecido",
 	Category:    "Utilidade",
 	Cooldown:    5 * time.Second,
-	Options: []*types.CommandOption{
-		{
-			Name:        "link",
-			Description: "O link para gerar o QR Code",
-			Type:        discordgo.ApplicationCommandOptionString,
-			Required:    true,
-		},
-	},
-	Run: func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error {
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		})
-		if err != nil {
-			return fmt.Errorf("falha ao responder à interação deferida: %w", err)
-		}
-
-		options := i.ApplicationCommandData().Options
-		var link string
-		for _, opt := range options {
-			if opt.Name == "link" {
-				link = opt.StringValue()
-				break
-			}
+	AllowPrefix: true,
+	Defer:       true,
+	Args:        qrCodeArgs{},
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		var args qrCodeArgs
+		if err := inv.Bind(&args); err != nil {
+			return err
 		}
 
-		if link == "" {
-			errorEmbed := &discordgo.MessageEmbed{
-				Title:       "❌ Erro",
-				Description: "Você deve fornecer um link válido.",
-				Color:       0xff0000,
-				Timestamp:   time.Now().Format(time.RFC3339),
-				Footer: &discordgo.MessageEmbedFooter{
-					Text:    "Devil • Criado por KevinFinalBoss",
-					IconURL: "https://github.com/kevinfinalboss.png",
-				},
-			}
-
-			_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-				Embeds: &[]*discordgo.MessageEmbed{errorEmbed},
-			})
-			if err != nil {
-				return fmt.Errorf("falha ao editar a resposta com o embed de erro: %w", err)
-			}
-
-			return nil
-		}
-
-		qr, err := qrcode.Encode(link, qrcode.Medium, 256)
+		qr, err := qrcode.Encode(args.Link, qrcode.Medium, 256)
 		if err != nil {
-			errorEmbed := &discordgo.MessageEmbed{
-				Title:       "❌ Erro",
-				Description: "Ocorreu um erro ao gerar o QR Code.",
-				Color:       0xff0000,
-				Timestamp:   time.Now().Format(time.RFC3339),
-				Footer: &discordgo.MessageEmbedFooter{
-					Text:    "Devil • Criado por KevinFinalBoss",
-					IconURL: "https://github.com/kevinfinalboss.png",
-				},
-			}
-
-			_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-				Embeds: &[]*discordgo.MessageEmbed{errorEmbed},
-			})
-			if err != nil {
-				return fmt.Errorf("falha ao editar a resposta com o embed de erro: %w", err)
-			}
-
-			return nil
+			return types.NewUserError("Ocorreu um erro ao gerar o QR Code.", err)
 		}
 
 		file := &discordgo.File{
@@ -111,7 +59,7 @@ var QRCodeCommand = &types.Command{
 			},
 		}
 
-		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		err = inv.Responder.Edit(&discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{successEmbed},
 			Files:  []*discordgo.File{file},
 		})
@@ -0,0 +1,115 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/models"
+	"github.com/kevinfinalboss/Void/internal/registry"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+func init() {
+	registry.RegisterCommand(SnipeCommand)
+}
+
+type snipeArgs struct {
+	Channel  *discordgo.Channel `option:"canal" description:"Canal onde a mensagem foi apagada" channels:"text,news,public_thread,private_thread,voice"`
+	Position int64              `option:"posicao" description:"Qual mensagem mostrar, da mais recente (1) para a mais antiga" min:"1" max:"10"`
+}
+
+var SnipeCommand = &types.Command{
+	Name:        "snipe",
+	Description: "Mostra a última mensagem apagada de um canal",
+	Category:    "Utilidade",
+	Cooldown:    5 * time.Second,
+	Args:        snipeArgs{},
+	// Deleted messages come from the message cache, which only fills with
+	// the guild messages and their content.
+	Intents: discordgo.IntentGuildMessages | discordgo.IntentMessageContent,
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		if inv.GuildID == "" {
+			return types.NewUserError("Este comando só pode ser usado em servidores.", nil)
+		}
+
+		var args snipeArgs
+		if err := inv.Bind(&args); err != nil {
+			return err
+		}
+
+		channelID := inv.Interaction.ChannelID
+		if args.Channel != nil {
+			channelID = args.Channel.ID
+		}
+
+		// Deleted messages must not leak out of channels the user cannot read.
+		perms, err := inv.Session.UserChannelPermissions(inv.UserID(), channelID, discordgo.WithContext(ctx))
+		if err != nil || perms&discordgo.PermissionViewChannel == 0 {
+			return types.NewUserError("Você não tem acesso a este canal.", err)
+		}
+
+		var sniped []*models.CachedMessage
+		for _, message := range inv.Messages.Sniped(inv.GuildID, channelID) {
+			if !message.AuthorBot {
+				sniped = append(sniped, message)
+			}
+		}
+
+		position := int(args.Position)
+		if position == 0 {
+			position = 1
+		}
+		if len(sniped) == 0 {
+			return types.NewUserError(fmt.Sprintf("Nenhuma mensagem apagada recentemente em <#%s>.", channelID), nil)
+		}
+		if position > len(sniped) {
+			return types.NewUserError(fmt.Sprintf("Só há %d mensagem(ns) apagada(s) recentemente em <#%s>.", len(sniped), channelID), nil)
+		}
+
+		return inv.Responder.Respond(&discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{snipeEmbed(sniped[position-1], channelID, position, len(sniped))},
+		})
+	},
+}
+
+func snipeEmbed(message *models.CachedMessage, channelID string, position, total int) *discordgo.MessageEmbed {
+	author := message.Author()
+
+	content := message.Content
+	if content == "" {
+		content = "*Sem conteúdo de texto*"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    author.Username,
+			IconURL: author.AvatarURL(""),
+		},
+		Description: content,
+		Color:       0x2B2D31,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Canal", Value: fmt.Sprintf("<#%s>", channelID), Inline: true},
+			{Name: "Apagada", Value: fmt.Sprintf("<t:%d:R>", message.DeletedAt.Unix()), Inline: true},
+		},
+		Timestamp: message.CreatedAt.Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Devil • Snipe %d/%d", position, total),
+		},
+	}
+
+	if len(message.Attachments) > 0 {
+		names := make([]string, 0, len(message.Attachments))
+		for _, a := range message.Attachments {
+			names = append(names, a.Summary())
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Anexos",
+			Value: strings.Join(names, "\n"),
+		})
+	}
+
+	return embed
+}
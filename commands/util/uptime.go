@@ -1,11 +1,11 @@
 package util
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/kevinfinalboss/Void/config"
 	"github.com/kevinfinalboss/Void/internal/registry"
 	"github.com/kevinfinalboss/Void/internal/types"
 )
@@ -19,8 +19,9 @@ var UptimeCommand = &types.Command{
 	Description: "Exibe o tempo de atividade do bot",
 	Category:    "Utilidade",
 	Cooldown:    5 * time.Second,
-	Run: func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error {
-		uptime := time.Since(cfg.BotStartTime)
+	AllowPrefix: true,
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		uptime := time.Since(inv.Config.BotStartTime)
 
 		days := int(uptime.Hours()) / 24
 		hours := int(uptime.Hours()) % 24
@@ -36,7 +37,7 @@ var UptimeCommand = &types.Command{
 			Fields: []*discordgo.MessageEmbedField{
 				{
 					Name:   "Iniciado em",
-					Value:  cfg.BotStartTime.Format("02/01/2006 15:04:05"),
+					Value:  inv.Config.BotStartTime.Format("02/01/2006 15:04:05"),
 					Inline: true,
 				},
 				{
@@ -47,16 +48,13 @@ var UptimeCommand = &types.Command{
 			},
 			Footer: &discordgo.MessageEmbedFooter{
 				Text:    "Devil • Tempo de Atividade",
-				IconURL: s.State.User.AvatarURL(""),
+				IconURL: inv.Session.State.User.AvatarURL(""),
 			},
 			Timestamp: time.Now().Format(time.RFC3339),
 		}
 
-		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Embeds: []*discordgo.MessageEmbed{embed},
-			},
+		return inv.Responder.Respond(&discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
 		})
 	},
 }
@@ -22,60 +22,48 @@ func init() {
 	registry.RegisterCommand(ExtractAudioCommand)
 }
 
+type extractAudioArgs struct {
+	Video *discordgo.MessageAttachment `option:"video" description:"O vídeo para extrair o áudio" required:"true"`
+}
+
 var ExtractAudioCommand = &types.Command{
 	Name:        "extract-audio",
 	Description: "Extrai o áudio de um vídeo em formato MP3",
 	Category:    "Utilidade",
 	Cooldown:    120 * time.Second,
-	Options: []*types.CommandOption{
-		{
-			Name:        "video",
-			Description: "O vídeo para extrair o áudio",
-			Type:        discordgo.ApplicationCommandOptionAttachment,
-			Required:    true,
-		},
-	},
-	Run: func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error {
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		})
-		if err != nil {
-			return fmt.Errorf("falha ao enviar a resposta inicial: %v", err)
-		}
-
-		options := i.ApplicationCommandData().Options
-		if len(options) == 0 {
-			return respondWithError(s, i, "Nenhum arquivo foi fornecido.")
+	AllowPrefix: true,
+	Defer:       true,
+	Timeout:     3 * time.Minute,
+	Requires:    []string{config.FeatureCloudinary},
+	Args:        extractAudioArgs{},
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		var args extractAudioArgs
+		if err := inv.Bind(&args); err != nil {
+			return err
 		}
+		attachment := args.Video
 
-		attachment := i.ApplicationCommandData().Resolved.Attachments[options[0].Value.(string)]
-		if attachment == nil {
-			return respondWithError(s, i, "Falha ao resolver o anexo.")
-		}
-
-		videoData, err := downloadFile(attachment.URL)
+		videoData, err := downloadFile(ctx, attachment.URL)
 		if err != nil {
-			return respondWithError(s, i, "Falha ao baixar o vídeo.")
+			return types.NewUserError("Falha ao baixar o vídeo.", err)
 		}
 
 		cld, err := cloudinary.NewFromParams(
-			cfg.Cloudinary.CloudName,
-			cfg.Cloudinary.APIKey,
-			cfg.Cloudinary.APISecret,
+			inv.Config.Cloudinary.CloudName,
+			inv.Config.Cloudinary.APIKey,
+			inv.Config.Cloudinary.APISecret,
 		)
 		if err != nil {
-			return respondWithError(s, i, "Erro ao configurar o Cloudinary.")
+			return types.NewUserError("Erro ao configurar o Cloudinary.", err)
 		}
 
-		ctx := context.Background()
-
 		uploadResult, err := cld.Upload.Upload(ctx, bytes.NewReader(videoData), uploader.UploadParams{
 			ResourceType:   "video",
 			Format:         "mp3",
 			Transformation: "f_mp3,ac_none",
 		})
 		if err != nil {
-			return respondWithError(s, i, "Erro ao extrair o áudio do vídeo.")
+			return types.NewUserError("Erro ao extrair o áudio do vídeo.", err)
 		}
 
 		fileName := strings.TrimSuffix(attachment.Filename, filepath.Ext(attachment.Filename))
@@ -107,26 +95,22 @@ var ExtractAudioCommand = &types.Command{
 			Timestamp: time.Now().Format(time.RFC3339),
 		}
 
-		var userID string
-		if i.Interaction.User != nil {
-			userID = i.Interaction.User.ID
-		} else if i.Interaction.Member != nil && i.Interaction.Member.User != nil {
-			userID = i.Interaction.Member.User.ID
-		} else {
-			return respondWithError(s, i, "Não foi possível identificar o usuário.")
+		userID := inv.UserID()
+		if userID == "" {
+			return types.NewUserError("Não foi possível identificar o usuário.", nil)
 		}
 
-		dmChannel, err := s.UserChannelCreate(userID)
+		dmChannel, err := inv.Session.UserChannelCreate(userID, discordgo.WithContext(ctx))
 		if err != nil {
-			return respondWithError(s, i, "Não foi possível enviar mensagem direta para você. Verifique se suas DMs estão abertas.")
+			return types.NewUserError("Não foi possível enviar mensagem direta para você. Verifique se suas DMs estão abertas.", err)
 		}
 
-		_, err = s.ChannelMessageSendEmbed(dmChannel.ID, embed)
+		_, err = inv.Session.ChannelMessageSendEmbed(dmChannel.ID, embed, discordgo.WithContext(ctx))
 		if err != nil {
 			return fmt.Errorf("falha ao enviar mensagem direta: %v", err)
 		}
 
-		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		err = inv.Responder.Edit(&discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{
 				{
 					Title:       "✅ Extração Concluída",
@@ -143,25 +127,13 @@ var ExtractAudioCommand = &types.Command{
 	},
 }
 
-func respondWithError(s *discordgo.Session, i *discordgo.InteractionCreate, message string) error {
-	embed := &discordgo.MessageEmbed{
-		Title:       "❌ Erro",
-		Description: message,
-		Color:       0xFF0000,
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: "Devil • Extrator de Áudio",
-		},
-		Timestamp: time.Now().Format(time.RFC3339),
+func downloadFile(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{embed},
-	})
-	return err
-}
-
-func downloadFile(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -20,64 +20,52 @@ func init() {
 	registry.RegisterCommand(WebmToMP4Command)
 }
 
+type webmToMP4Args struct {
+	Video *discordgo.MessageAttachment `option:"video" description:"O vídeo WEBM para converter" required:"true"`
+}
+
 var WebmToMP4Command = &types.Command{
 	Name:        "convert-webm",
 	Description: "Converte um vídeo WEBM para formato MP4",
 	Category:    "Utilidade",
 	Cooldown:    30 * time.Second,
-	Options: []*types.CommandOption{
-		{
-			Name:        "video",
-			Description: "O vídeo WEBM para converter",
-			Type:        discordgo.ApplicationCommandOptionAttachment,
-			Required:    true,
-		},
-	},
-	Run: func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error {
-		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
-		})
-		if err != nil {
-			return fmt.Errorf("falha ao enviar a resposta inicial: %v", err)
-		}
-
-		options := i.ApplicationCommandData().Options
-		if len(options) == 0 {
-			return respondWithError(s, i, "Nenhum arquivo foi fornecido.")
-		}
-
-		attachment := i.ApplicationCommandData().Resolved.Attachments[options[0].Value.(string)]
-		if attachment == nil {
-			return respondWithError(s, i, "Falha ao resolver o anexo.")
+	AllowPrefix: true,
+	Defer:       true,
+	Timeout:     3 * time.Minute,
+	Requires:    []string{config.FeatureCloudinary},
+	Args:        webmToMP4Args{},
+	Run: func(ctx context.Context, inv *types.Invocation) error {
+		var args webmToMP4Args
+		if err := inv.Bind(&args); err != nil {
+			return err
 		}
+		attachment := args.Video
 
 		if !isWebmFormat(attachment.Filename) {
-			return respondWithError(s, i, "Por favor, envie um arquivo no formato WEBM.")
+			return types.NewUserError("Por favor, envie um arquivo no formato WEBM.", nil)
 		}
 
-		videoData, err := downloadFile(attachment.URL)
+		videoData, err := downloadFile(ctx, attachment.URL)
 		if err != nil {
-			return respondWithError(s, i, "Falha ao baixar o vídeo.")
+			return types.NewUserError("Falha ao baixar o vídeo.", err)
 		}
 
 		cld, err := cloudinary.NewFromParams(
-			cfg.Cloudinary.CloudName,
-			cfg.Cloudinary.APIKey,
-			cfg.Cloudinary.APISecret,
+			inv.Config.Cloudinary.CloudName,
+			inv.Config.Cloudinary.APIKey,
+			inv.Config.Cloudinary.APISecret,
 		)
 		if err != nil {
-			return respondWithError(s, i, "Erro ao configurar o Cloudinary.")
+			return types.NewUserError("Erro ao configurar o Cloudinary.", err)
 		}
 
-		ctx := context.Background()
-
 		uploadResult, err := cld.Upload.Upload(ctx, bytes.NewReader(videoData), uploader.UploadParams{
 			ResourceType:   "video",
 			Format:         "mp4",
 			Transformation: "f_mp4",
 		})
 		if err != nil {
-			return respondWithError(s, i, "Erro ao converter o vídeo.")
+			return types.NewUserError("Erro ao converter o vídeo.", err)
 		}
 
 		embed := &discordgo.MessageEmbed{
@@ -107,26 +95,22 @@ var WebmToMP4Command = &types.Command{
 			Timestamp: time.Now().Format(time.RFC3339),
 		}
 
-		var userID string
-		if i.Interaction.User != nil {
-			userID = i.Interaction.User.ID
-		} else if i.Interaction.Member != nil && i.Interaction.Member.User != nil {
-			userID = i.Interaction.Member.User.ID
-		} else {
-			return respondWithError(s, i, "Não foi possível identificar o usuário.")
+		userID := inv.UserID()
+		if userID == "" {
+			return types.NewUserError("Não foi possível identificar o usuário.", nil)
 		}
 
-		dmChannel, err := s.UserChannelCreate(userID)
+		dmChannel, err := inv.Session.UserChannelCreate(userID, discordgo.WithContext(ctx))
 		if err != nil {
-			return respondWithError(s, i, "Não foi possível enviar mensagem direta para você. Verifique se suas DMs estão abertas.")
+			return types.NewUserError("Não foi possível enviar mensagem direta para você. Verifique se suas DMs estão abertas.", err)
 		}
 
-		_, err = s.ChannelMessageSendEmbed(dmChannel.ID, embed)
+		_, err = inv.Session.ChannelMessageSendEmbed(dmChannel.ID, embed, discordgo.WithContext(ctx))
 		if err != nil {
 			return fmt.Errorf("falha ao enviar mensagem direta: %v", err)
 		}
 
-		_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		err = inv.Responder.Edit(&discordgo.WebhookEdit{
 			Embeds: &[]*discordgo.MessageEmbed{
 				{
 					Title:       "✅ Conversão Concluída",
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -14,12 +15,57 @@ type Config struct {
 		Status   string   `yaml:"status"`
 		ClientID string   `yaml:"client_id"`
 		Devs     []string `yaml:"developers"`
+		Prefix   string   `yaml:"prefix"`
+		// Intents replaces the gateway intents computed from the registered
+		// handlers, e.g. ["guilds", "guild_messages"].
+		Intents  []string `yaml:"intents"`
+		Commands struct {
+			Global    bool     `yaml:"global"`
+			DevGuilds []string `yaml:"dev_guilds"`
+			DryRun    bool     `yaml:"dry_run"`
+			// DrainTimeout is how long shutdown waits for running
+			// commands before closing the sessions.
+			DrainTimeout time.Duration `yaml:"drain_timeout"`
+		} `yaml:"commands"`
 		Sharding struct {
-			Enabled     bool `yaml:"enabled"`
-			TotalShards int  `yaml:"total_shards"`
+			Enabled bool `yaml:"enabled"`
+			// TotalShards is the number of shards to open. Zero uses the
+			// count recommended by Discord.
+			TotalShards         int           `yaml:"total_shards"`
+			HealthCheckInterval time.Duration `yaml:"health_check_interval"`
+			// EventTimeout restarts shards that receive no events for this
+			// long. Zero disables the check, which suits small bots whose
+			// shards may legitimately stay quiet.
+			EventTimeout time.Duration `yaml:"event_timeout"`
+			// Resume saves the gateway sessions on shutdown and resumes
+			// them on the next start. It is off by default: discordgo
+			// exposes no session state, so it is reached through its
+			// unexported fields, and reconnects on the regular gateway
+			// rather than the resume URL Discord asks for.
+			Resume bool `yaml:"resume"`
+			// ResumeWindow is how long after shutdown the saved gateway
+			// sessions are resumed by the next start.
+			ResumeWindow time.Duration `yaml:"resume_window"`
 		} `yaml:"sharding"`
 	} `yaml:"discord"`
 
+	// Cluster splits the shards across several processes. A coordinator
+	// assigns shard ranges to the workers connecting to Address.
+	Cluster struct {
+		Enabled bool `yaml:"enabled"`
+		// Role is "coordinator" or "worker".
+		Role string `yaml:"role"`
+		// Network is "tcp" or "unix".
+		Network  string `yaml:"network"`
+		Address  string `yaml:"address"`
+		WorkerID string `yaml:"worker_id"`
+		// Workers is how many workers the coordinator waits for, at most
+		// StartupGrace, before assigning the first shards.
+		Workers           int           `yaml:"workers"`
+		StartupGrace      time.Duration `yaml:"startup_grace"`
+		HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+	} `yaml:"cluster"`
+
 	Server struct {
 		Port     int    `yaml:"port"`
 		Mode     string `yaml:"mode"`
@@ -53,6 +99,11 @@ type Config struct {
 	Logger struct {
 		Level string `yaml:"level"`
 		File  string `yaml:"file"`
+		// Format is "console" (the default) or "json".
+		Format string `yaml:"format"`
+		// Levels overrides Level for named loggers, e.g.
+		// {"commands": "debug", "cache": "warn"}.
+		Levels map[string]string `yaml:"levels"`
 	} `yaml:"logger"`
 
 	Cloudinary struct {
@@ -70,19 +121,48 @@ type Config struct {
 		APIKey string `yaml:"api_token"`
 	} `yaml:"pterodactyl"`
 
+	Cache struct {
+		Messages struct {
+			// MaxMessages caps the cache across all guilds and MaxPerGuild
+			// caps any single guild. The least recently used messages are
+			// evicted first.
+			MaxMessages      int           `yaml:"max_messages"`
+			MaxPerGuild      int           `yaml:"max_per_guild"`
+			MaxContentLength int           `yaml:"max_content_length"`
+			TTL              time.Duration `yaml:"ttl"`
+			SnipeHistory     int           `yaml:"snipe_history"`
+			// Persist also stores messages in MongoDB for PersistWindow, so
+			// edits and deletes survive restarts and memory evictions.
+			Persist       bool          `yaml:"persist"`
+			PersistWindow time.Duration `yaml:"persist_window"`
+		} `yaml:"messages"`
+	} `yaml:"cache"`
+
 	Debug        bool      `yaml:"debug"`
 	BotStartTime time.Time `yaml:"-"`
+
+	// disabled holds the optional features Validate disabled.
+	disabled Problems
 }
 
+// Load reads the configuration from path and the environment, which takes
+// precedence. A missing file is not an error, so the whole configuration can
+// come from the environment. The result still needs Validate.
 func Load(path string) (*Config, error) {
+	var cfg Config
+
 	file, err := os.ReadFile(path)
-	if err != nil {
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(file, &cfg); err != nil {
+			return nil, err
+		}
+	case !os.IsNotExist(err):
 		return nil, err
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(file, &cfg); err != nil {
-		return nil, err
+	if err := applyEnv(&cfg); err != nil {
+		return nil, fmt.Errorf("invalid environment: %v", err)
 	}
 
 	if cfg.Server.Port == 0 {
@@ -91,10 +171,60 @@ func Load(path string) (*Config, error) {
 	if cfg.Server.Mode == "" {
 		cfg.Server.Mode = "release"
 	}
+	if cfg.Discord.Prefix == "" {
+		cfg.Discord.Prefix = "!"
+	}
 	if cfg.Server.Host == "" {
 		cfg.Server.Host = "0.0.0.0"
 	}
 
+	if cfg.Discord.Commands.DrainTimeout <= 0 {
+		cfg.Discord.Commands.DrainTimeout = 3 * time.Minute
+	}
+	if cfg.Discord.Sharding.HealthCheckInterval <= 0 {
+		cfg.Discord.Sharding.HealthCheckInterval = 30 * time.Second
+	}
+	if cfg.Discord.Sharding.ResumeWindow <= 0 {
+		cfg.Discord.Sharding.ResumeWindow = 2 * time.Minute
+	}
+
+	cluster := &cfg.Cluster
+	if cluster.Network == "" {
+		cluster.Network = "tcp"
+	}
+	if cluster.Address == "" {
+		cluster.Address = "127.0.0.1:7070"
+	}
+	if cluster.Workers <= 0 {
+		cluster.Workers = 1
+	}
+	if cluster.StartupGrace <= 0 {
+		cluster.StartupGrace = 30 * time.Second
+	}
+	if cluster.HeartbeatInterval <= 0 {
+		cluster.HeartbeatInterval = 5 * time.Second
+	}
+
+	messages := &cfg.Cache.Messages
+	if messages.MaxMessages <= 0 {
+		messages.MaxMessages = 50000
+	}
+	if messages.MaxPerGuild <= 0 {
+		messages.MaxPerGuild = 1000
+	}
+	if messages.MaxContentLength <= 0 {
+		messages.MaxContentLength = 2000
+	}
+	if messages.TTL <= 0 {
+		messages.TTL = 6 * time.Hour
+	}
+	if messages.SnipeHistory <= 0 {
+		messages.SnipeHistory = 10
+	}
+	if messages.PersistWindow <= 0 {
+		messages.PersistWindow = 24 * time.Hour
+	}
+
 	cfg.BotStartTime = time.Now()
 
 	return &cfg, nil
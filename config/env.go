@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envPrefix starts the environment variable of every setting. The rest of
+// the name is the setting's YAML path in upper case, joined by underscores,
+// e.g. VOID_DISCORD_TOKEN for discord.token. Appending _FILE reads the
+// value from a file instead, as used by Docker secrets.
+const envPrefix = "VOID"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv overrides the settings set in the environment. Lists are
+// comma-separated and maps are comma-separated key=value pairs.
+func applyEnv(cfg *Config) error {
+	var errs []error
+	applyEnvStruct(reflect.ValueOf(cfg).Elem(), envPrefix, &errs)
+	return errors.Join(errs...)
+}
+
+func applyEnvStruct(v reflect.Value, prefix string, errs *[]error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if !field.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+
+		name := prefix + "_" + strings.ToUpper(tag)
+		if field.Type.Kind() == reflect.Struct {
+			applyEnvStruct(v.Field(i), name, errs)
+			continue
+		}
+
+		value, ok, err := lookupEnv(name)
+		if err != nil {
+			*errs = append(*errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := setField(v.Field(i), value); err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %v", name, err))
+		}
+	}
+}
+
+// lookupEnv returns the value of name, or the content of the file named by
+// name_FILE.
+func lookupEnv(name string) (string, bool, error) {
+	if path, ok := os.LookupEnv(name + "_FILE"); ok {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("%s_FILE: %v", name, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), true, nil
+	}
+	value, ok := os.LookupEnv(name)
+	return value, ok, nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		items := make(map[string]string)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			key, val, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("%q is not key=value", item)
+			}
+			items[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+		field.Set(reflect.ValueOf(items))
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
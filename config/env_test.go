@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secret, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("VOID_DISCORD_TOKEN_FILE", secret)
+	t.Setenv("VOID_DISCORD_DEVELOPERS", "1, 2,,3")
+	t.Setenv("VOID_DISCORD_SHARDING_TOTAL_SHARDS", "4")
+	t.Setenv("VOID_DISCORD_SHARDING_EVENT_TIMEOUT", "90s")
+	t.Setenv("VOID_DISCORD_COMMANDS_DRY_RUN", "true")
+	t.Setenv("VOID_LOGGER_LEVELS", "commands=debug, cache = warn")
+
+	var cfg Config
+	cfg.Discord.Token = "from-yaml"
+	if err := applyEnv(&cfg); err != nil {
+		t.Fatalf("applyEnv() = %v", err)
+	}
+
+	if cfg.Discord.Token != "from-file" {
+		t.Errorf("token = %q, want from-file", cfg.Discord.Token)
+	}
+	if want := []string{"1", "2", "3"}; !reflect.DeepEqual(cfg.Discord.Devs, want) {
+		t.Errorf("developers = %q, want %q", cfg.Discord.Devs, want)
+	}
+	if cfg.Discord.Sharding.TotalShards != 4 {
+		t.Errorf("total shards = %d, want 4", cfg.Discord.Sharding.TotalShards)
+	}
+	if cfg.Discord.Sharding.EventTimeout != 90*time.Second {
+		t.Errorf("event timeout = %v, want 90s", cfg.Discord.Sharding.EventTimeout)
+	}
+	if !cfg.Discord.Commands.DryRun {
+		t.Error("dry run = false, want true")
+	}
+	if want := map[string]string{"commands": "debug", "cache": "warn"}; !reflect.DeepEqual(cfg.Logger.Levels, want) {
+		t.Errorf("levels = %v, want %v", cfg.Logger.Levels, want)
+	}
+}
+
+func TestApplyEnvErrors(t *testing.T) {
+	t.Setenv("VOID_SERVER_PORT", "eighty")
+	t.Setenv("VOID_DEBUG", "maybe")
+	t.Setenv("VOID_LOGGER_LEVELS", "commands")
+	t.Setenv("VOID_MONGODB_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	var cfg Config
+	err := applyEnv(&cfg)
+	if err == nil {
+		t.Fatal("applyEnv() = nil, want errors")
+	}
+	for _, name := range []string{"VOID_SERVER_PORT", "VOID_DEBUG", "VOID_LOGGER_LEVELS", "VOID_MONGODB_PASSWORD_FILE"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("applyEnv() = %v, want an error naming %s", err, name)
+		}
+	}
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	t.Setenv("VOID_DISCORD_TOKEN", "token")
+
+	cfg, err := Load(filepath.Join(t.TempDir(), "config.yaml"))
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if cfg.Discord.Token != "token" {
+		t.Errorf("token = %q, want token", cfg.Discord.Token)
+	}
+	if cfg.Server.Port != 80 || cfg.Discord.Prefix != "!" {
+		t.Errorf("defaults not applied: port %d, prefix %q", cfg.Server.Port, cfg.Discord.Prefix)
+	}
+}
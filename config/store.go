@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// watchInterval is how often Watch checks the config file for changes.
+const watchInterval = 2 * time.Second
+
+// Subscriber is notified after a reload with the previous and the new
+// configuration. The new one must not be modified.
+type Subscriber func(old, cfg *Config)
+
+// Store holds the current configuration and reloads it from its file.
+// Reloads that fail to load or validate keep the current configuration.
+//
+// Subsystems that read the configuration per use, like command invocations,
+// pick up reloads through Get. The ones that copy settings at startup
+// subscribe to apply the changes they support; settings nobody applies,
+// like sharding or the cluster, still need a restart.
+type Store struct {
+	path    string
+	prepare func(*Config)
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex
+	subscribers []Subscriber
+	modTime     time.Time
+	size        int64
+}
+
+// NewStore loads and validates the configuration at path. prepare, if not
+// nil, adjusts every loaded configuration before validation, e.g. to apply
+// command-line flags.
+func NewStore(path string, prepare func(*Config)) (*Store, error) {
+	s := &Store{path: path, prepare: prepare}
+	s.modTime, s.size = s.stat()
+
+	cfg, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.current.Store(cfg)
+	return s, nil
+}
+
+func (s *Store) Get() *Config {
+	return s.current.Load()
+}
+
+func (s *Store) Subscribe(fn Subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Reload loads the configuration again and, if it is valid, makes it
+// current and notifies the subscribers.
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.modTime, s.size = s.stat()
+
+	cfg, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	old := s.current.Load()
+	cfg.BotStartTime = old.BotStartTime
+	s.current.Store(cfg)
+
+	for _, fn := range s.subscribers {
+		fn(old, cfg)
+	}
+	return nil
+}
+
+// Watch reloads the configuration whenever its file changes, until stop is
+// closed. report is called with the result of every reload.
+func (s *Store) Watch(stop <-chan struct{}, report func(error)) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		modTime, size := s.stat()
+		s.mu.Lock()
+		changed := !modTime.Equal(s.modTime) || size != s.size
+		s.mu.Unlock()
+
+		if changed {
+			report(s.Reload())
+		}
+	}
+}
+
+func (s *Store) load() (*Config, error) {
+	cfg, err := Load(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %v", s.path, err)
+	}
+	if s.prepare != nil {
+		s.prepare(cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (s *Store) stat() (time.Time, int64) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Optional features. Commands that need one declare it in their Requires
+// and are not registered while it is disabled by missing or malformed
+// settings.
+const (
+	FeatureCloudinary  = "cloudinary"
+	FeatureConvertAPI  = "convertapi"
+	FeaturePterodactyl = "pterodactyl"
+	FeatureRiot        = "riot"
+	FeatureGroq        = "groq"
+	FeatureLavalink    = "lavalink"
+)
+
+// Problems groups configuration problems by feature.
+type Problems map[string][]string
+
+func (p Problems) add(feature, format string, args ...interface{}) {
+	p[feature] = append(p[feature], fmt.Sprintf(format, args...))
+}
+
+func (p Problems) String() string {
+	features := make([]string, 0, len(p))
+	for feature := range p {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+
+	var b strings.Builder
+	for _, feature := range features {
+		fmt.Fprintf(&b, "%s:\n", feature)
+		for _, problem := range p[feature] {
+			fmt.Fprintf(&b, "  - %s\n", problem)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Validate checks the settings. Problems with the settings the bot cannot
+// run without are returned as an error listing all of them. Problems with an
+// optional feature disable it instead; Disabled reports them.
+func (c *Config) Validate() error {
+	required := make(Problems)
+	optional := make(Problems)
+
+	c.validateDiscord(required)
+	c.validateServer(required)
+	c.validateCluster(required)
+	c.validateLogger(required)
+	if !c.Cluster.Enabled || c.Cluster.Role != "coordinator" {
+		c.validateMongoDB(required)
+	}
+
+	requireAll(optional, FeatureCloudinary, map[string]string{
+		"cloud_name": c.Cloudinary.CloudName,
+		"api_key":    c.Cloudinary.APIKey,
+		"api_secret": c.Cloudinary.APISecret,
+	})
+	requireAll(optional, FeatureConvertAPI, map[string]string{
+		"secret": c.ConvertAPI.Secret,
+	})
+	if requireAll(optional, FeaturePterodactyl, map[string]string{
+		"url":       c.Pterodactyl.URL,
+		"api_token": c.Pterodactyl.APIKey,
+	}) {
+		if err := checkURL(c.Pterodactyl.URL, "http", "https"); err != nil {
+			optional.add(FeaturePterodactyl, "url: %v", err)
+		}
+	}
+	requireAll(optional, FeatureRiot, map[string]string{
+		"api_key": c.Riot.APIKey,
+	})
+	requireAll(optional, FeatureGroq, map[string]string{
+		"api_key": c.Groq.APIKey,
+	})
+	if requireAll(optional, FeatureLavalink, map[string]string{
+		"host":     c.Lavalink.Host,
+		"password": c.Lavalink.Password,
+	}) && (c.Lavalink.Port < 1 || c.Lavalink.Port > 65535) {
+		optional.add(FeatureLavalink, "port %d is out of range", c.Lavalink.Port)
+	}
+
+	c.disabled = optional
+	if len(required) > 0 {
+		return fmt.Errorf("invalid configuration:\n%s", required)
+	}
+	return nil
+}
+
+// Disabled returns the optional features disabled by Validate, with the
+// reasons.
+func (c *Config) Disabled() Problems {
+	return c.disabled
+}
+
+// Enabled reports whether none of features is disabled.
+func (c *Config) Enabled(features ...string) bool {
+	for _, feature := range features {
+		if _, disabled := c.disabled[feature]; disabled {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Config) validateDiscord(p Problems) {
+	if c.Discord.Token == "" {
+		p.add("discord", "token is required (discord.token or %s_DISCORD_TOKEN)", envPrefix)
+	}
+	if c.Discord.ClientID != "" && !isSnowflake(c.Discord.ClientID) {
+		p.add("discord", "client_id %q is not a Discord ID", c.Discord.ClientID)
+	}
+	if c.Discord.GuildID != "" && !isSnowflake(c.Discord.GuildID) {
+		p.add("discord", "guild_id %q is not a Discord ID", c.Discord.GuildID)
+	}
+	for _, id := range c.Discord.Devs {
+		if !isSnowflake(id) {
+			p.add("discord", "developer %q is not a Discord ID", id)
+		}
+	}
+	for _, id := range c.Discord.Commands.DevGuilds {
+		if !isSnowflake(id) {
+			p.add("discord", "dev guild %q is not a Discord ID", id)
+		}
+	}
+	if c.Discord.Sharding.TotalShards < 0 {
+		p.add("discord", "sharding.total_shards cannot be negative")
+	}
+}
+
+func (c *Config) validateServer(p Problems) {
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		p.add("server", "port %d is out of range", c.Server.Port)
+	}
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		p.add("server", "mode %q must be debug, release or test", c.Server.Mode)
+	}
+}
+
+func (c *Config) validateCluster(p Problems) {
+	if !c.Cluster.Enabled {
+		return
+	}
+	switch c.Cluster.Role {
+	case "coordinator", "worker":
+	default:
+		p.add("cluster", "role %q must be coordinator or worker", c.Cluster.Role)
+	}
+	switch c.Cluster.Network {
+	case "tcp", "unix":
+	default:
+		p.add("cluster", "network %q must be tcp or unix", c.Cluster.Network)
+	}
+}
+
+func (c *Config) validateLogger(p Problems) {
+	checkLevel := func(name, level string) {
+		switch strings.ToLower(level) {
+		case "", "debug", "info", "warn", "warning", "error":
+		default:
+			p.add("logger", "%s %q must be debug, info, warn or error", name, level)
+		}
+	}
+
+	checkLevel("level", c.Logger.Level)
+	for name, level := range c.Logger.Levels {
+		checkLevel("level of "+name, level)
+	}
+	switch strings.ToLower(c.Logger.Format) {
+	case "", "console", "json":
+	default:
+		p.add("logger", "format %q must be console or json", c.Logger.Format)
+	}
+}
+
+func (c *Config) validateMongoDB(p Problems) {
+	if c.MongoDB.URI == "" {
+		p.add("mongodb", "uri is required (mongodb.uri or %s_MONGODB_URI)", envPrefix)
+	} else if err := checkURL(withoutUserinfo(c.MongoDB.URI), "mongodb", "mongodb+srv"); err != nil {
+		p.add("mongodb", "uri: %v", err)
+	}
+	if c.MongoDB.Database == "" {
+		p.add("mongodb", "database is required")
+	}
+	if strings.Contains(c.MongoDB.URI, "<db_password>") && c.MongoDB.Password == "" {
+		p.add("mongodb", "password is required by the <db_password> placeholder of uri")
+	}
+}
+
+// requireAll adds a problem for each empty setting of feature, unless they
+// are all empty, which only means the feature is not configured. It reports
+// whether all of them are set.
+func requireAll(p Problems, feature string, settings map[string]string) bool {
+	var missing []string
+	for name, value := range settings {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	switch {
+	case len(missing) == 0:
+		return true
+	case len(missing) == len(settings):
+		p.add(feature, "not configured")
+	default:
+		for _, name := range missing {
+			p.add(feature, "%s is required", name)
+		}
+	}
+	return false
+}
+
+func checkURL(raw string, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme && u.Host != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be a %s URL", strings.Join(schemes, " or "))
+}
+
+// withoutUserinfo strips the credentials from a connection string, which
+// may hold the <db_password> placeholder or characters url.Parse rejects,
+// and must not end up in error messages.
+func withoutUserinfo(raw string) string {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		return raw
+	}
+	authority := rest
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		authority = rest[:i]
+	}
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		rest = rest[i+1:]
+	}
+	return scheme + "://" + rest
+}
+
+func isSnowflake(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
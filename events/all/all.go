@@ -0,0 +1,6 @@
+package all
+
+import (
+	_ "github.com/kevinfinalboss/Void/events/ready"
+	// Importe outros pacotes de eventos aqui, se houver
+)
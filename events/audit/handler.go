@@ -0,0 +1,485 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/cache"
+	"github.com/kevinfinalboss/Void/internal/database"
+	"github.com/kevinfinalboss/Void/internal/logger"
+	"github.com/kevinfinalboss/Void/internal/models"
+)
+
+const (
+	colorCreated = 0x00FF00
+	colorRemoved = 0xFF0000
+	colorUpdated = 0xFFA500
+	colorVoice   = 0x5865F2
+)
+
+// Handler posts the events of each guild to the audit channel configured in
+// its settings. It also feeds message edits and deletes into the message
+// cache, which supplies the previous content of the messages.
+type Handler struct {
+	db       *database.MongoDB
+	logger   *logger.Logger
+	messages *cache.MessageCache
+
+	// channels keeps the audited fields of every known channel, since
+	// channel updates carry no copy of the previous state. The channels of
+	// a guild are forgotten when the bot leaves it.
+	mu       sync.Mutex
+	channels map[string]channelSnapshot
+}
+
+type channelSnapshot struct {
+	GuildID          string
+	Name             string
+	Topic            string
+	ParentID         string
+	RateLimitPerUser int
+	NSFW             bool
+}
+
+func NewHandler(db *database.MongoDB, logger *logger.Logger, messages *cache.MessageCache) *Handler {
+	return &Handler{
+		db:       db,
+		logger:   logger.Named("audit"),
+		messages: messages,
+		channels: make(map[string]channelSnapshot),
+	}
+}
+
+// HandleGuildCreate records the channels of the guild so later updates can
+// be compared against them.
+func (h *Handler) HandleGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	for _, c := range g.Channels {
+		h.storeChannel(g.ID, c)
+	}
+}
+
+// HandleGuildDelete forgets the channels and cached messages of a guild the
+// bot left. Guilds that only became unavailable are kept.
+func (h *Handler) HandleGuildDelete(s *discordgo.Session, g *discordgo.GuildDelete) {
+	if g.Unavailable {
+		return
+	}
+
+	h.mu.Lock()
+	for id, c := range h.channels {
+		if c.GuildID == g.ID {
+			delete(h.channels, id)
+		}
+	}
+	h.mu.Unlock()
+
+	h.messages.RemoveGuild(g.ID)
+}
+
+func (h *Handler) HandleMessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Partial updates, such as embeds being unfurled, carry no author.
+	if m.GuildID == "" || m.Author == nil {
+		return
+	}
+
+	previous := h.messages.Update(m.Message)
+	if m.Author.Bot {
+		return
+	}
+
+	before := "*Conteúdo anterior não disponível*"
+	if previous != nil {
+		// Updates that only change attachments or embeds keep the content.
+		if previous.Content == m.Content {
+			return
+		}
+		before = quote(previous.Content)
+	}
+
+	h.send(s, m.GuildID, models.AuditMessages, &discordgo.MessageEmbed{
+		Title:       "✏️ Mensagem editada",
+		Description: fmt.Sprintf("[Ir para a mensagem](%s) em <#%s>", messageLink(m.GuildID, m.ChannelID, m.ID), m.ChannelID),
+		Color:       colorUpdated,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Autor", Value: userLabel(m.Author)},
+			{Name: "Antes", Value: before},
+			{Name: "Depois", Value: quote(m.Content)},
+		},
+	})
+}
+
+func (h *Handler) HandleMessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
+	if m.GuildID == "" {
+		return
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Canal", Value: fmt.Sprintf("<#%s>", m.ChannelID), Inline: true},
+	}
+
+	before := h.messages.Delete(m.GuildID, m.ChannelID, m.ID)
+	if before == nil {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "Conteúdo",
+			Value: "*A mensagem não estava em cache*",
+		})
+	} else {
+		if before.AuthorBot {
+			return
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Autor", Value: userLabel(before.Author()), Inline: true})
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Conteúdo", Value: quote(before.Content)})
+		if len(before.Attachments) > 0 {
+			names := make([]string, 0, len(before.Attachments))
+			for _, a := range before.Attachments {
+				names = append(names, a.Summary())
+			}
+			fields = append(fields, &discordgo.MessageEmbedField{Name: "Anexos", Value: truncate(strings.Join(names, "\n"), 1024)})
+		}
+	}
+
+	h.send(s, m.GuildID, models.AuditMessages, &discordgo.MessageEmbed{
+		Title:  "🗑️ Mensagem apagada",
+		Color:  colorRemoved,
+		Fields: fields,
+	})
+}
+
+func (h *Handler) HandleGuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	if m.User == nil {
+		return
+	}
+
+	created, _ := discordgo.SnowflakeTimestamp(m.User.ID)
+	h.send(s, m.GuildID, models.AuditMembers, &discordgo.MessageEmbed{
+		Title:     "📥 Membro entrou",
+		Color:     colorCreated,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: m.User.AvatarURL("")},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Membro", Value: userLabel(m.User)},
+			{Name: "Conta criada", Value: fmt.Sprintf("<t:%d:R>", created.Unix())},
+		},
+	})
+}
+
+func (h *Handler) HandleGuildMemberRemove(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+	if m.User == nil {
+		return
+	}
+
+	h.send(s, m.GuildID, models.AuditMembers, &discordgo.MessageEmbed{
+		Title:     "📤 Membro saiu",
+		Color:     colorRemoved,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: m.User.AvatarURL("")},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Membro", Value: userLabel(m.User)},
+		},
+	})
+}
+
+func (h *Handler) HandleGuildBanAdd(s *discordgo.Session, b *discordgo.GuildBanAdd) {
+	if b.User == nil {
+		return
+	}
+
+	h.send(s, b.GuildID, models.AuditBans, &discordgo.MessageEmbed{
+		Title: "🔨 Membro banido",
+		Color: colorRemoved,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Membro", Value: userLabel(b.User)},
+		},
+	})
+}
+
+func (h *Handler) HandleGuildBanRemove(s *discordgo.Session, b *discordgo.GuildBanRemove) {
+	if b.User == nil {
+		return
+	}
+
+	h.send(s, b.GuildID, models.AuditBans, &discordgo.MessageEmbed{
+		Title: "♻️ Banimento removido",
+		Color: colorCreated,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Membro", Value: userLabel(b.User)},
+		},
+	})
+}
+
+// HandleGuildMemberUpdate logs nickname and role changes. Updates of members
+// that were not in the state cache cannot be compared and are skipped.
+func (h *Handler) HandleGuildMemberUpdate(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
+	if m.Member == nil || m.User == nil || m.BeforeUpdate == nil {
+		return
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	if m.BeforeUpdate.Nick != m.Nick {
+		fields = append(fields,
+			&discordgo.MessageEmbedField{Name: "Apelido anterior", Value: orNone(m.BeforeUpdate.Nick), Inline: true},
+			&discordgo.MessageEmbedField{Name: "Novo apelido", Value: orNone(m.Nick), Inline: true},
+		)
+	}
+
+	added, removed := diffRoles(m.BeforeUpdate.Roles, m.Roles)
+	if len(added) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Cargos adicionados", Value: roleList(added)})
+	}
+	if len(removed) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Cargos removidos", Value: roleList(removed)})
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	h.send(s, m.GuildID, models.AuditMemberUpdates, &discordgo.MessageEmbed{
+		Title:       "👤 Membro atualizado",
+		Description: userLabel(m.User),
+		Color:       colorUpdated,
+		Fields:      fields,
+	})
+}
+
+func (h *Handler) HandleChannelCreate(s *discordgo.Session, c *discordgo.ChannelCreate) {
+	if c.GuildID == "" {
+		return
+	}
+	h.storeChannel(c.GuildID, c.Channel)
+
+	h.send(s, c.GuildID, models.AuditChannels, &discordgo.MessageEmbed{
+		Title: "📁 Canal criado",
+		Color: colorCreated,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Canal", Value: fmt.Sprintf("<#%s> (`%s`)", c.ID, c.Name), Inline: true},
+			{Name: "Tipo", Value: channelType(c.Type), Inline: true},
+		},
+	})
+}
+
+func (h *Handler) HandleChannelDelete(s *discordgo.Session, c *discordgo.ChannelDelete) {
+	if c.GuildID == "" {
+		return
+	}
+	h.mu.Lock()
+	delete(h.channels, c.ID)
+	h.mu.Unlock()
+
+	h.send(s, c.GuildID, models.AuditChannels, &discordgo.MessageEmbed{
+		Title: "🗑️ Canal apagado",
+		Color: colorRemoved,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Canal", Value: fmt.Sprintf("`%s`", c.Name), Inline: true},
+			{Name: "Tipo", Value: channelType(c.Type), Inline: true},
+		},
+	})
+}
+
+// HandleChannelUpdate logs changes to the name, topic, category, slowmode
+// and NSFW flag. Position and permission changes are ignored, as are
+// channels that were not seen before the update.
+func (h *Handler) HandleChannelUpdate(s *discordgo.Session, c *discordgo.ChannelUpdate) {
+	if c.GuildID == "" {
+		return
+	}
+	before, ok := h.storeChannel(c.GuildID, c.Channel)
+	if !ok {
+		return
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	change := func(name, old, new string) {
+		if old != new {
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:  name,
+				Value: fmt.Sprintf("%s → %s", truncate(orNone(old), 500), truncate(orNone(new), 500)),
+			})
+		}
+	}
+	change("Nome", before.Name, c.Name)
+	change("Tópico", before.Topic, c.Topic)
+	change("Categoria", channelRef(before.ParentID), channelRef(c.ParentID))
+	change("Modo lento", slowmode(before.RateLimitPerUser), slowmode(c.RateLimitPerUser))
+	change("NSFW", yesNo(before.NSFW), yesNo(c.NSFW))
+
+	if len(fields) == 0 {
+		return
+	}
+
+	h.send(s, c.GuildID, models.AuditChannels, &discordgo.MessageEmbed{
+		Title:       "🔧 Canal atualizado",
+		Description: fmt.Sprintf("<#%s>", c.ID),
+		Color:       colorUpdated,
+		Fields:      fields,
+	})
+}
+
+func (h *Handler) HandleVoiceStateUpdate(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
+	if v.GuildID == "" || v.Member == nil || v.Member.User == nil || v.Member.User.Bot {
+		return
+	}
+
+	previous := ""
+	if v.BeforeUpdate != nil {
+		previous = v.BeforeUpdate.ChannelID
+	}
+	if previous == v.ChannelID {
+		return
+	}
+
+	var title, description string
+	switch {
+	case previous == "":
+		title = "🔊 Entrou em um canal de voz"
+		description = fmt.Sprintf("%s entrou em <#%s>", userLabel(v.Member.User), v.ChannelID)
+	case v.ChannelID == "":
+		title = "🔇 Saiu de um canal de voz"
+		description = fmt.Sprintf("%s saiu de <#%s>", userLabel(v.Member.User), previous)
+	default:
+		title = "🔀 Mudou de canal de voz"
+		description = fmt.Sprintf("%s: <#%s> → <#%s>", userLabel(v.Member.User), previous, v.ChannelID)
+	}
+
+	h.send(s, v.GuildID, models.AuditVoice, &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       colorVoice,
+	})
+}
+
+func (h *Handler) send(s *discordgo.Session, guildID, category string, embed *discordgo.MessageEmbed) {
+	settings, err := h.db.GetGuildSettings(guildID)
+	if err != nil {
+		h.logger.Error("Failed to load audit settings", "guild", guildID, "error", err)
+		return
+	}
+	if !settings.AuditEnabled(category) {
+		return
+	}
+
+	embed.Timestamp = time.Now().Format(time.RFC3339)
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: "Devil • Audit"}
+
+	if _, err := s.ChannelMessageSendEmbed(settings.AuditLogChannel, embed); err != nil {
+		h.logger.Error("Failed to send audit log", "guild", guildID, "error", err)
+	}
+}
+
+// storeChannel records c, a channel of guildID, and returns the snapshot it
+// replaced, if any. Channels sent with a guild create carry no guild ID.
+func (h *Handler) storeChannel(guildID string, c *discordgo.Channel) (channelSnapshot, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	previous, ok := h.channels[c.ID]
+	h.channels[c.ID] = channelSnapshot{
+		GuildID:          guildID,
+		Name:             c.Name,
+		Topic:            c.Topic,
+		ParentID:         c.ParentID,
+		RateLimitPerUser: c.RateLimitPerUser,
+		NSFW:             c.NSFW,
+	}
+	return previous, ok
+}
+
+func diffRoles(before, after []string) (added, removed []string) {
+	old := make(map[string]bool, len(before))
+	for _, id := range before {
+		old[id] = true
+	}
+	current := make(map[string]bool, len(after))
+	for _, id := range after {
+		current[id] = true
+		if !old[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range before {
+		if !current[id] {
+			removed = append(removed, id)
+		}
+	}
+	return added, removed
+}
+
+func userLabel(u *discordgo.User) string {
+	return fmt.Sprintf("%s (`%s`)", u.Mention(), u.ID)
+}
+
+func roleList(ids []string) string {
+	mentions := make([]string, 0, len(ids))
+	for _, id := range ids {
+		mentions = append(mentions, fmt.Sprintf("<@&%s>", id))
+	}
+	return truncate(strings.Join(mentions, ", "), 1024)
+}
+
+func messageLink(guildID, channelID, messageID string) string {
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", guildID, channelID, messageID)
+}
+
+func channelRef(id string) string {
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf("<#%s>", id)
+}
+
+func channelType(t discordgo.ChannelType) string {
+	switch t {
+	case discordgo.ChannelTypeGuildText:
+		return "Texto"
+	case discordgo.ChannelTypeGuildVoice:
+		return "Voz"
+	case discordgo.ChannelTypeGuildCategory:
+		return "Categoria"
+	case discordgo.ChannelTypeGuildNews:
+		return "Anúncios"
+	case discordgo.ChannelTypeGuildStageVoice:
+		return "Palco"
+	case discordgo.ChannelTypeGuildForum:
+		return "Fórum"
+	case discordgo.ChannelTypeGuildNewsThread, discordgo.ChannelTypeGuildPublicThread, discordgo.ChannelTypeGuildPrivateThread:
+		return "Tópico"
+	}
+	return "Outro"
+}
+
+func slowmode(seconds int) string {
+	if seconds == 0 {
+		return ""
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "Sim"
+	}
+	return "Não"
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "*Nenhum*"
+	}
+	return s
+}
+
+func quote(content string) string {
+	if content == "" {
+		return "*Sem conteúdo de texto*"
+	}
+	return truncate(content, 1024)
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -1,6 +1,7 @@
 package guild
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -9,6 +10,13 @@ import (
 	"github.com/kevinfinalboss/Void/internal/models"
 )
 
+const (
+	// reconcileTimeout bounds how long the reconciliation after Ready waits
+	// for the guilds of the session to become available.
+	reconcileTimeout  = 2 * time.Minute
+	reconcileInterval = 2 * time.Second
+)
+
 type Handler struct {
 	db     *database.MongoDB
 	logger *logger.Logger
@@ -17,18 +25,22 @@ type Handler struct {
 func NewHandler(db *database.MongoDB, logger *logger.Logger) *Handler {
 	return &Handler{
 		db:     db,
-		logger: logger,
+		logger: logger.Named("guild"),
 	}
 }
 
 func (h *Handler) HandleGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	if g.Unavailable {
+		return
+	}
+
 	guild := &models.Guild{
 		GuildID:     g.ID,
 		Name:        g.Name,
 		OwnerID:     g.OwnerID,
 		MemberCount: g.MemberCount,
 		IsActive:    true,
-		JoinedAt:    time.Now(),
+		JoinedAt:    g.JoinedAt,
 		Region:      g.Region,
 		Icon:        g.Icon,
 		Features:    g.Features,
@@ -36,19 +48,27 @@ func (h *Handler) HandleGuildCreate(s *discordgo.Session, g *discordgo.GuildCrea
 	}
 
 	if err := h.db.UpsertGuild(guild); err != nil {
-		h.logger.Error("Failed to upsert guild:", err)
+		h.logger.Error("Failed to upsert guild", "guild", g.ID, "error", err)
 		return
 	}
 }
 
 func (h *Handler) HandleGuildDelete(s *discordgo.Session, g *discordgo.GuildDelete) {
+	// Unavailable guilds are in an outage, the bot is still a member.
+	if g.Unavailable {
+		h.logger.Info("Guild became unavailable", "guild", g.ID)
+		return
+	}
+
+	h.db.InvalidateGuildSettings(g.ID)
+
 	now := time.Now()
 	if err := h.db.UpdateGuildStatus(g.ID, false, &now); err != nil {
-		h.logger.Error("Failed to update guild status:", err)
+		h.logger.Error("Failed to update guild status", "guild", g.ID, "error", err)
 		return
 	}
 
-	h.logger.Info("Bot removed from guild ID:", g.ID)
+	h.logger.Info("Bot removed from guild", "guild", g.ID)
 }
 
 func (h *Handler) HandleGuildUpdate(s *discordgo.Session, g *discordgo.GuildUpdate) {
@@ -65,21 +85,109 @@ func (h *Handler) HandleGuildUpdate(s *discordgo.Session, g *discordgo.GuildUpda
 	}
 
 	if err := h.db.UpsertGuild(guild); err != nil {
-		h.logger.Error("Failed to update guild:", err)
+		h.logger.Error("Failed to update guild", "guild", guild.GuildID, "error", err)
 		return
 	}
 
-	h.logger.Info("Guild updated:", guild.Name, "(ID:", guild.GuildID, ")")
+	h.logger.Info("Guild updated", "guild", guild.GuildID, "name", guild.Name)
 }
 
 func (h *Handler) HandleGuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
 	if err := h.db.UpdateMemberCount(m.GuildID, 1); err != nil {
-		h.logger.Error("Failed to update member count:", err)
+		h.logger.Error("Failed to update member count", "guild", m.GuildID, "error", err)
 	}
 }
 
 func (h *Handler) HandleGuildMemberRemove(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
 	if err := h.db.UpdateMemberCount(m.GuildID, -1); err != nil {
-		h.logger.Error("Failed to update member count:", err)
+		h.logger.Error("Failed to update member count", "guild", m.GuildID, "error", err)
+	}
+}
+
+// HandleReady reconciles the stored guilds with the gateway once the guilds
+// listed in Ready have become available: member counts are refreshed from
+// the state and guilds of this shard the bot is no longer in are marked
+// inactive.
+func (h *Handler) HandleReady(s *discordgo.Session, r *discordgo.Ready) {
+	ids := make([]string, 0, len(r.Guilds))
+	for _, g := range r.Guilds {
+		ids = append(ids, g.ID)
+	}
+	go h.reconcile(s, ids)
+}
+
+func (h *Handler) reconcile(s *discordgo.Session, guildIDs []string) {
+	h.waitForGuilds(s, guildIDs)
+
+	current := make(map[string]bool, len(guildIDs))
+	for _, id := range guildIDs {
+		current[id] = true
+
+		g, err := s.State.Guild(id)
+		if err != nil || g.Unavailable {
+			continue
+		}
+		if err := h.db.SetMemberCount(id, g.MemberCount); err != nil {
+			h.logger.Error("Failed to refresh member count", "guild", g.ID, "error", err)
+		}
+	}
+
+	stored, err := h.db.ActiveGuildIDs()
+	if err != nil {
+		h.logger.Error("Failed to load active guilds", "shard", s.ShardID, "error", err)
+		return
+	}
+
+	var left []string
+	for _, id := range stored {
+		if !current[id] && onShard(id, s.ShardID, s.ShardCount) {
+			left = append(left, id)
+		}
+	}
+
+	if err := h.db.MarkGuildsInactive(left, time.Now()); err != nil {
+		h.logger.Error("Failed to mark guilds inactive", "shard", s.ShardID, "error", err)
+		return
+	}
+
+	h.logger.Info("Reconciled guilds", "shard", s.ShardID, "guilds", len(guildIDs), "inactive", len(left))
+}
+
+// waitForGuilds blocks until every guild is available in the state or
+// reconcileTimeout passes.
+func (h *Handler) waitForGuilds(s *discordgo.Session, guildIDs []string) {
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+	deadline := time.After(reconcileTimeout)
+
+	for {
+		pending := 0
+		for _, id := range guildIDs {
+			if g, err := s.State.Guild(id); err != nil || g.Unavailable {
+				pending++
+			}
+		}
+		if pending == 0 {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			h.logger.Warn("Reconciling with guilds still unavailable", "shard", s.ShardID, "pending", pending)
+			return
+		}
+	}
+}
+
+// onShard reports whether the guild is served by the given shard.
+func onShard(guildID string, shardID, shardCount int) bool {
+	if shardCount <= 1 {
+		return true
+	}
+	id, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		return false
 	}
+	return int((id>>22)%uint64(shardCount)) == shardID
 }
@@ -4,28 +4,54 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/kevinfinalboss/Void/commands/admin"
 	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/events/audit"
 	"github.com/kevinfinalboss/Void/events/guild"
+	"github.com/kevinfinalboss/Void/internal/cache"
 	"github.com/kevinfinalboss/Void/internal/commands"
 	"github.com/kevinfinalboss/Void/internal/database"
 	"github.com/kevinfinalboss/Void/internal/events"
 	"github.com/kevinfinalboss/Void/internal/logger"
+	"github.com/kevinfinalboss/Void/internal/types"
 )
 
 type Bot struct {
 	sessions     []*discordgo.Session
-	config       *config.Config
+	config       atomic.Pointer[config.Config]
 	logger       *logger.Logger
 	cmdHandler   *commands.Handler
 	eventHandler *events.Handler
 	db           *database.MongoDB
 	guildHandler *guild.Handler
-	mu           sync.RWMutex
+	auditHandler *audit.Handler
+	messages     *cache.MessageCache
+	limiter      *IdentifyLimiter
+	identify     IdentifyGate
+	supervisor   *supervisor
+	cluster      types.Cluster
+	intents      discordgo.Intent
+	// disabledIntents are the privileged intents the sessions do not get.
+	// Whatever needs one of them is not attached.
+	disabledIntents discordgo.Intent
+	// registersCommands is set while this process serves shard 0, which
+	// registers the application commands for the whole cluster.
+	registersCommands bool
+	// handlersReady is set once the handlers are created and the commands
+	// registered.
+	handlersReady bool
+	superviseOnce sync.Once
+	mu            sync.RWMutex
+}
+
+// currentConfig returns the configuration, which follows reloads.
+func (b *Bot) currentConfig() *config.Config {
+	return b.config.Load()
 }
 
 func New(cfg *config.Config, l *logger.Logger) (*Bot, error) {
@@ -64,18 +90,40 @@ func New(cfg *config.Config, l *logger.Logger) (*Bot, error) {
 			return nil, errors.New("failed to create guild handler")
 		}
 
-		return &Bot{
-			config:       cfg,
-			logger:       l,
+		messages, err := cache.NewMessageCache(cfg, db, l)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+
+		limiter := NewIdentifyLimiter()
+
+		b := &Bot{
+			logger:       l.Named("bot"),
 			db:           db,
 			sessions:     make([]*discordgo.Session, 0),
 			guildHandler: guildHandler,
-		}, nil
+			auditHandler: audit.NewHandler(db, l, messages),
+			messages:     messages,
+			limiter:      limiter,
+			identify:     limiter,
+			supervisor:   newSupervisor(cfg, l, limiter),
+		}
+		b.cluster = b
+		b.config.Store(cfg)
+		if errResume != nil && cfg.Discord.Sharding.Resume {
+			b.logger.Warn("Gateway sessions cannot be resumed, shards will identify", "error", errResume)
+		}
+		return b, nil
 	case <-ctx.Done():
 		return nil, errors.New("timeout initializing bot dependencies")
 	}
 }
 
+// setupHandlers creates the command and event handlers and registers the
+// application commands. Commands are global to the application, so this
+// runs once, with the first session to be set up, and only the process
+// serving shard 0 registers them.
 func (b *Bot) setupHandlers(session *discordgo.Session) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 	defer cancel()
@@ -84,13 +132,17 @@ func (b *Bot) setupHandlers(session *discordgo.Session) error {
 		return errors.New("session cannot be nil")
 	}
 
-	if b.config == nil || b.logger == nil || b.db == nil || b.guildHandler == nil {
+	if b.currentConfig() == nil || b.logger == nil || b.db == nil || b.guildHandler == nil || b.auditHandler == nil {
 		return errors.New("bot dependencies not properly initialized")
 	}
 
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if b.handlersReady {
+		return nil
+	}
+
 	errChan := make(chan error, 2)
 	setupDone := make(chan struct{})
 
@@ -98,16 +150,18 @@ func (b *Bot) setupHandlers(session *discordgo.Session) error {
 		defer close(setupDone)
 
 		if b.cmdHandler == nil {
-			cmdHandler := commands.NewHandler(session, b.config, b.logger)
+			cmdHandler := commands.NewHandler(session, b.currentConfig(), b.logger, b.db, b.messages)
 			if cmdHandler == nil {
 				errChan <- errors.New("failed to create command handler")
 				return
 			}
 			b.cmdHandler = cmdHandler
 		}
+		b.cmdHandler.SetRegister(b.registersCommands)
+		b.cmdHandler.SetCluster(b.cluster)
 
 		if b.eventHandler == nil {
-			eventHandler := events.NewHandler(session, b.config, b.logger)
+			eventHandler := events.NewHandler(b.currentConfig(), b.logger)
 			if eventHandler == nil {
 				errChan <- errors.New("failed to create event handler")
 				return
@@ -115,13 +169,6 @@ func (b *Bot) setupHandlers(session *discordgo.Session) error {
 			b.eventHandler = eventHandler
 		}
 
-		admin.SetDatabase(b.db)
-
-		session.AddHandler(b.cmdHandler.HandleCommand)
-		session.AddHandler(b.guildHandler.HandleGuildCreate)
-		session.AddHandler(b.guildHandler.HandleGuildDelete)
-		session.AddHandler(admin.HandleConfigButton)
-
 		var wg sync.WaitGroup
 		wg.Add(2)
 
@@ -148,10 +195,35 @@ func (b *Bot) setupHandlers(session *discordgo.Session) error {
 	case err := <-errChan:
 		return err
 	case <-setupDone:
+		select {
+		case err := <-errChan:
+			return err
+		default:
+		}
+		if err := b.resolveIntents(session); err != nil {
+			return err
+		}
+		b.handlersReady = true
 		return nil
 	}
 }
 
+// attachHandlers registers the gateway handlers on session. Every shard
+// needs its own, since Discord only delivers the events of a guild to the
+// shard that serves it.
+func (b *Bot) attachHandlers(session *discordgo.Session) {
+	// Prefix commands declare their intents themselves, see
+	// commands.Handler.Intents.
+	session.AddHandler(b.cmdHandler.HandleMessage)
+	for _, h := range b.gatewayHandlers() {
+		if b.enabled(h) {
+			session.AddHandler(h.handler)
+		}
+	}
+
+	b.eventHandler.Attach(session)
+}
+
 func (b *Bot) Start() error {
 	startCtx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
 	defer cancel()
@@ -161,11 +233,11 @@ func (b *Bot) Start() error {
 	}
 
 	b.mu.Lock()
-	if b.config == nil {
+	if b.currentConfig() == nil {
 		b.mu.Unlock()
 		return errors.New("bot configuration is nil")
 	}
-	isSharded := b.config.Discord.Sharding.Enabled
+	isSharded := b.currentConfig().Discord.Sharding.Enabled
 	b.mu.Unlock()
 
 	errChan := make(chan error, 1)
@@ -183,102 +255,169 @@ func (b *Bot) Start() error {
 	select {
 	case <-startCtx.Done():
 		return errors.New("bot startup timed out")
-	case err := <-errChan:
-		return err
 	case <-startDone:
-		return nil
+		return <-errChan
 	}
 }
 
 func (b *Bot) startSingle() error {
-	sessionCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	if b == nil || b.config == nil {
+	if b == nil || b.currentConfig() == nil {
 		return errors.New("invalid bot state")
 	}
 
-	session, err := discordgo.New("Bot " + b.config.Discord.Token)
-	if err != nil {
-		return fmt.Errorf("failed to create discord session: %v", err)
+	if err := b.StartShards([]int{0}, 1); err != nil {
+		b.logger.Error("Failed to setup session", "error", err)
+		return err
 	}
 
-	b.mu.Lock()
-	b.sessions = []*discordgo.Session{session}
-	b.mu.Unlock()
-
-	setupDone := make(chan error, 1)
-	go func() {
-		setupDone <- b.setupSession(session, 0, 1)
-	}()
-
-	select {
-	case <-sessionCtx.Done():
-		return errors.New("session setup timed out")
-	case err := <-setupDone:
-		if err != nil {
-			b.logger.Error("Failed to setup session: " + err.Error())
-			return err
-		}
-		b.logger.Info("Bot started successfully in single mode")
-		return nil
-	}
+	b.logger.Info("Bot started successfully in single mode")
+	return nil
 }
 
 func (b *Bot) startSharded() error {
-	if b == nil || b.config == nil {
+	if b == nil || b.currentConfig() == nil {
 		return errors.New("invalid bot state")
 	}
 
-	totalShards := b.config.Discord.Sharding.TotalShards
+	gateway, err := GatewayInfo(b.currentConfig().Discord.Token)
+	if err != nil {
+		return err
+	}
+
+	totalShards := b.currentConfig().Discord.Sharding.TotalShards
+	if totalShards <= 0 {
+		totalShards = gateway.Shards
+		b.logger.Info("Using the shard count recommended by Discord", "shards", totalShards)
+	}
 	if totalShards <= 0 {
 		return errors.New("invalid shard count")
 	}
 
+	limit := gateway.SessionStartLimit
+	if limit.Remaining < totalShards {
+		return fmt.Errorf("only %d of %d session starts left for %d shards, resets in %s",
+			limit.Remaining, limit.Total, totalShards, time.Duration(limit.ResetAfter)*time.Millisecond)
+	}
+	// Shards identify in buckets of max_concurrency, see IdentifyLimiter.
+	b.limiter.SetConcurrency(limit.MaxConcurrency)
+
+	shardIDs := make([]int, totalShards)
+	for i := range shardIDs {
+		shardIDs[i] = i
+	}
+
+	if err := b.StartShards(shardIDs, totalShards); err != nil {
+		return fmt.Errorf("errors starting sharded mode: %v", err)
+	}
+
+	b.logger.Info("Bot started successfully in sharded mode")
+	return nil
+}
+
+// StartShards opens the given shards out of totalShards. Cluster workers
+// call it with the range assigned by the coordinator.
+func (b *Bot) StartShards(shardIDs []int, totalShards int) error {
+	sessions := make([]*discordgo.Session, len(shardIDs))
+
 	b.mu.Lock()
-	b.sessions = make([]*discordgo.Session, totalShards)
+	b.registersCommands = slices.Contains(shardIDs, 0)
+	if b.cmdHandler != nil {
+		b.cmdHandler.SetRegister(b.registersCommands)
+	}
 	b.mu.Unlock()
 
 	var wg sync.WaitGroup
-	errChan := make(chan error, totalShards)
-	semaphore := make(chan struct{}, 5)
+	errChan := make(chan error, len(shardIDs))
 
-	for i := 0; i < totalShards; i++ {
+	for i, shardID := range shardIDs {
 		wg.Add(1)
-		go func(shardID int) {
+		go func(i, shardID int) {
 			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
 
-			session, err := discordgo.New("Bot " + b.config.Discord.Token)
+			session, err := discordgo.New("Bot " + b.currentConfig().Discord.Token)
 			if err != nil {
 				errChan <- fmt.Errorf("failed to create discord session for shard %d: %v", shardID, err)
 				return
 			}
-
-			b.mu.Lock()
-			b.sessions[shardID] = session
-			b.mu.Unlock()
+			sessions[i] = session
 
 			if err := b.setupSession(session, shardID, totalShards); err != nil {
 				errChan <- fmt.Errorf("failed to setup shard %d: %v", shardID, err)
 			}
-		}(i)
+		}(i, shardID)
 	}
 
 	wg.Wait()
 	close(errChan)
 
-	var errors []error
+	b.mu.Lock()
+	for _, session := range sessions {
+		if session != nil {
+			b.sessions = append(b.sessions, session)
+		}
+	}
+	b.mu.Unlock()
+
+	var errs []error
 	for err := range errChan {
-		errors = append(errors, err)
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+
+	b.superviseOnce.Do(func() { go b.supervisor.run() })
+	return nil
+}
+
+// StopShards closes every open shard, leaving the bot ready to start a new
+// set of shards. The gateway sessions are saved so whichever process opens
+// the shards next can resume them.
+func (b *Bot) StopShards() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	defer cancel()
+
+	b.mu.Lock()
+	sessions := b.sessions
+	b.sessions = nil
+	b.mu.Unlock()
+
+	b.supervisor.reset()
+
+	var wg sync.WaitGroup
+	sessionErrors := make(chan error, len(sessions))
+
+	for _, session := range sessions {
+		wg.Add(1)
+		go func(s *discordgo.Session) {
+			defer wg.Done()
+			if err := b.closeSession(s); err != nil {
+				sessionErrors <- fmt.Errorf("failed to close session: %v", err)
+			}
+		}(session)
 	}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("errors starting sharded mode: %v", errors)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return errors.New("session shutdown timed out")
+	case <-done:
 	}
 
-	b.logger.Info("Bot started successfully in sharded mode")
+	close(sessionErrors)
+
+	var errs []error
+	for err := range sessionErrors {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("errors closing sessions: %v", errs)
+	}
 	return nil
 }
 
@@ -289,12 +428,17 @@ func (b *Bot) setupSession(session *discordgo.Session, shardID, totalShards int)
 
 	session.ShardID = shardID
 	session.ShardCount = totalShards
-	session.Identify.Intents = discordgo.IntentsAll
 
-	if shardID == 0 {
-		if err := b.setupHandlers(session); err != nil {
-			return fmt.Errorf("failed to setup handlers: %v", err)
-		}
+	if err := b.setupHandlers(session); err != nil {
+		return fmt.Errorf("failed to setup handlers: %v", err)
+	}
+	session.Identify.Intents = b.intents
+	b.attachHandlers(session)
+	b.supervisor.watch(session)
+
+	// Resuming does not count against the identify limits.
+	if !b.restoreSession(session) {
+		b.identify.Wait(shardID)
 	}
 
 	if err := session.Open(); err != nil {
@@ -305,59 +449,77 @@ func (b *Bot) setupSession(session *discordgo.Session, shardID, totalShards int)
 }
 
 func (b *Bot) Stop() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
-	defer cancel()
-
-	b.mu.Lock()
-	defer b.mu.Unlock()
-
 	if b == nil {
 		return errors.New("bot instance is nil")
 	}
 
-	var wg sync.WaitGroup
-	sessionErrors := make(chan error, len(b.sessions))
+	// Stop supervising first so closing shards are not restarted.
+	b.supervisor.shutdown()
 
-	for _, session := range b.sessions {
-		if session != nil {
-			wg.Add(1)
-			go func(s *discordgo.Session) {
-				defer wg.Done()
-				if err := s.Close(); err != nil {
-					sessionErrors <- fmt.Errorf("failed to close session: %v", err)
-				}
-			}(session)
+	var errs []error
+
+	// Let running commands reply before their sessions and the database
+	// go away.
+	if b.cmdHandler != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), b.currentConfig().Discord.Commands.DrainTimeout)
+		err := b.cmdHandler.Drain(ctx)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to drain commands: %v", err))
 		}
 	}
 
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
-
-	select {
-	case <-ctx.Done():
-		return errors.New("session shutdown timed out")
-	case <-done:
+	if err := b.StopShards(); err != nil {
+		errs = append(errs, err)
 	}
 
 	if b.db != nil {
 		if err := b.db.Close(); err != nil {
-			return fmt.Errorf("failed to close database: %v", err)
+			errs = append(errs, fmt.Errorf("failed to close database: %v", err))
 		}
 	}
 
-	close(sessionErrors)
-
-	var errors []error
-	for err := range sessionErrors {
-		errors = append(errors, err)
-	}
-
-	if len(errors) > 0 {
-		return fmt.Errorf("errors during shutdown: %v", errors)
+	if len(errs) > 0 {
+		return fmt.Errorf("errors during shutdown: %v", errs)
 	}
 
 	return nil
 }
+
+// ShardStatus returns the health of every shard.
+func (b *Bot) ShardStatus() []ShardStatus {
+	return b.supervisor.status()
+}
+
+// Database returns the database of the bot.
+func (b *Bot) Database() *database.MongoDB {
+	return b.db
+}
+
+// SetIdentifyGate replaces the local identify limiter, e.g. with one shared
+// by every process of a cluster. It must be called before starting shards.
+func (b *Bot) SetIdentifyGate(gate IdentifyGate) {
+	b.identify = gate
+	b.supervisor.identify = gate
+}
+
+// SetCluster sets what commands use for queries spanning every process. It
+// must be called before starting shards. Outside cluster mode the bot
+// answers for itself.
+func (b *Bot) SetCluster(c types.Cluster) {
+	b.cluster = c
+}
+
+// Stats sums the shards and guilds of this process.
+func (b *Bot) Stats(ctx context.Context) (types.ClusterStats, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := types.ClusterStats{Processes: 1, Shards: len(b.sessions)}
+	for _, session := range b.sessions {
+		session.State.RLock()
+		stats.Guilds += len(session.State.Guilds)
+		session.State.RUnlock()
+	}
+	return stats, nil
+}
@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/events"
+)
+
+// Application flags telling which privileged intents are enabled in the
+// developer portal. The limited flags apply to bots in fewer than 100
+// guilds that are not verified yet.
+const (
+	applicationGatewayPresence              = 1 << 12
+	applicationGatewayPresenceLimited       = 1 << 13
+	applicationGatewayGuildMembers          = 1 << 14
+	applicationGatewayGuildMembersLimited   = 1 << 15
+	applicationGatewayMessageContent        = 1 << 18
+	applicationGatewayMessageContentLimited = 1 << 19
+)
+
+// gatewayHandler is a handler attached to every session. intents lists
+// what it needs besides the intents that deliver its event.
+type gatewayHandler struct {
+	handler interface{}
+	intents discordgo.Intent
+}
+
+func (b *Bot) gatewayHandlers() []gatewayHandler {
+	return []gatewayHandler{
+		{handler: b.cmdHandler.HandleCommand},
+		{handler: b.guildHandler.HandleGuildCreate},
+		{handler: b.guildHandler.HandleGuildDelete},
+		{handler: b.guildHandler.HandleGuildUpdate},
+		{handler: b.guildHandler.HandleGuildMemberAdd},
+		{handler: b.guildHandler.HandleGuildMemberRemove},
+		{handler: b.guildHandler.HandleReady},
+
+		{handler: b.messages.HandleMessageCreate, intents: discordgo.IntentMessageContent},
+		{handler: b.auditHandler.HandleGuildCreate},
+		{handler: b.auditHandler.HandleGuildDelete},
+		{handler: b.auditHandler.HandleMessageUpdate, intents: discordgo.IntentMessageContent},
+		{handler: b.auditHandler.HandleMessageDelete},
+		{handler: b.auditHandler.HandleGuildMemberAdd},
+		{handler: b.auditHandler.HandleGuildMemberRemove},
+		{handler: b.auditHandler.HandleGuildBanAdd},
+		{handler: b.auditHandler.HandleGuildBanRemove},
+		{handler: b.auditHandler.HandleGuildMemberUpdate},
+		{handler: b.auditHandler.HandleChannelCreate},
+		{handler: b.auditHandler.HandleChannelDelete},
+		{handler: b.auditHandler.HandleChannelUpdate},
+		{handler: b.auditHandler.HandleVoiceStateUpdate},
+	}
+}
+
+// requiredIntents returns the intents needed by every handler, command and
+// event, keyed by what needs them.
+func (b *Bot) requiredIntents() map[string]discordgo.Intent {
+	// The state cache is built from the guild events.
+	required := map[string]discordgo.Intent{"state": discordgo.IntentGuilds}
+
+	for _, h := range b.gatewayHandlers() {
+		required["handler "+events.HandlerName(h.handler)] |= events.HandlerIntents(h.handler) | h.intents
+	}
+	for name, intents := range b.cmdHandler.Intents() {
+		required[name] |= intents
+	}
+	for name, intents := range b.eventHandler.Intents() {
+		required[name] |= intents
+	}
+	return required
+}
+
+// resolveIntents decides the intents the sessions identify with: the
+// configured ones or, by default, the ones the handlers need. Privileged
+// intents that are not enabled for the application are left out, since
+// identifying with them closes the gateway with 4014, and the handlers,
+// commands and events that need a privileged intent the sessions do not
+// get are disabled. It warns about every handler left without an intent.
+func (b *Bot) resolveIntents(session *discordgo.Session) error {
+	required := b.requiredIntents()
+
+	var needed discordgo.Intent
+	for _, need := range required {
+		needed |= need
+	}
+
+	intents := needed
+
+	if len(b.currentConfig().Discord.Intents) > 0 {
+		configured, err := events.ParseIntents(b.currentConfig().Discord.Intents)
+		if err != nil {
+			return fmt.Errorf("invalid intents: %v", err)
+		}
+		intents = configured
+	}
+	notRequested := needed &^ intents
+
+	var notEnabled discordgo.Intent
+	if privileged := intents & events.PrivilegedIntents; privileged != 0 {
+		app, err := session.Application("@me")
+		if err != nil {
+			b.logger.Error("Failed to check privileged intents", "error", err)
+		} else {
+			notEnabled = privileged &^ enabledPrivilegedIntents(app.Flags)
+		}
+	}
+	intents &^= notEnabled
+
+	b.intents = intents
+	b.disabledIntents = (notRequested | notEnabled) & events.PrivilegedIntents
+	b.cmdHandler.DisableIntents(b.disabledIntents)
+	b.eventHandler.DisableIntents(b.disabledIntents)
+
+	b.warnIntents(required, notRequested, "not requested")
+	b.warnIntents(required, notEnabled, "not enabled in the developer portal")
+	b.logger.Info("Gateway intents", "intents", strings.Join(events.IntentNames(intents), ", "))
+	return nil
+}
+
+// warnIntents logs every handler that needs one of the missing intents,
+// and whether it is disabled for it.
+func (b *Bot) warnIntents(required map[string]discordgo.Intent, missing discordgo.Intent, reason string) {
+	if missing == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(required))
+	for name := range required {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		lacking := required[name] & missing
+		if lacking == 0 {
+			continue
+		}
+		msg := "Handler needs missing intents"
+		if lacking&b.disabledIntents != 0 {
+			msg = "Handler disabled for missing intents"
+		}
+		b.logger.Warn(msg, "handler", name,
+			"intents", strings.Join(events.IntentNames(lacking), ", "), "reason", reason)
+	}
+}
+
+// enabled reports whether a gateway handler gets all the privileged intents
+// it needs.
+func (b *Bot) enabled(h gatewayHandler) bool {
+	return (events.HandlerIntents(h.handler)|h.intents)&b.disabledIntents == 0
+}
+
+func enabledPrivilegedIntents(flags int) discordgo.Intent {
+	var enabled discordgo.Intent
+	if flags&(applicationGatewayPresence|applicationGatewayPresenceLimited) != 0 {
+		enabled |= discordgo.IntentGuildPresences
+	}
+	if flags&(applicationGatewayGuildMembers|applicationGatewayGuildMembersLimited) != 0 {
+		enabled |= discordgo.IntentGuildMembers
+	}
+	if flags&(applicationGatewayMessageContent|applicationGatewayMessageContentLimited) != 0 {
+		enabled |= discordgo.IntentMessageContent
+	}
+	return enabled
+}
@@ -0,0 +1,57 @@
+package bot
+
+import "github.com/kevinfinalboss/Void/config"
+
+// ApplyConfig applies a reloaded configuration: the bot, its handlers and
+// new invocations see cfg, the message cache and shard supervisor take its
+// limits, the presence follows Discord.Status and the commands are
+// registered again when an optional feature was enabled or disabled.
+// Settings used only to connect, such as the token and the database, still
+// need a restart. It is meant to be subscribed to the config.Store.
+func (b *Bot) ApplyConfig(old, cfg *config.Config) {
+	b.config.Store(cfg)
+	if b.messages != nil {
+		b.messages.ApplyConfig(old, cfg)
+	}
+	b.supervisor.setConfig(cfg)
+
+	b.mu.RLock()
+	cmdHandler, eventHandler := b.cmdHandler, b.eventHandler
+	sessions := append(b.sessions[:0:0], b.sessions...)
+	b.mu.RUnlock()
+
+	if cmdHandler != nil {
+		cmdHandler.SetConfig(cfg)
+	}
+	if eventHandler != nil {
+		eventHandler.SetConfig(cfg)
+	}
+
+	if cfg.Discord.Status != old.Discord.Status {
+		for _, s := range sessions {
+			if err := s.UpdateGameStatus(0, cfg.Discord.Status); err != nil {
+				b.logger.Error("Failed to update status", "shard", s.ShardID, "error", err)
+			}
+		}
+	}
+
+	if cmdHandler != nil && !sameFeatures(old.Disabled(), cfg.Disabled()) {
+		go func() {
+			if err := cmdHandler.LoadCommands(); err != nil {
+				b.logger.Error("Failed to reload commands", "error", err)
+			}
+		}()
+	}
+}
+
+func sameFeatures(a, b config.Problems) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for feature := range a {
+		if _, ok := b[feature]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,259 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/models"
+)
+
+// resumableCloseCode closes a shard without invalidating its gateway
+// session. Discord discards sessions closed with 1000 or 1001, which is what
+// discordgo's Close sends.
+const resumableCloseCode = 4000
+
+// closeSession closes a shard and, when resuming is enabled, saves its
+// gateway session for the next process serving the shard.
+func (b *Bot) closeSession(s *discordgo.Session) error {
+	if !b.resumable() {
+		return s.Close()
+	}
+
+	if err := s.CloseWithCode(resumableCloseCode); err != nil {
+		return err
+	}
+
+	sessionID, sequence := resumeState(s)
+	if sessionID == "" || sequence == 0 {
+		return nil
+	}
+
+	err := b.db.SaveGatewaySession(&models.GatewaySession{
+		ShardID:    s.ShardID,
+		ShardCount: s.ShardCount,
+		SessionID:  sessionID,
+		Sequence:   sequence,
+		Intents:    int(s.Identify.Intents),
+		SavedAt:    time.Now(),
+	})
+	if err != nil {
+		b.logger.Error("Failed to save gateway session", "shard", s.ShardID, "error", err)
+	}
+	return nil
+}
+
+// restoreSession prepares s to resume the gateway session saved for its
+// shard, if there is one that is recent enough and was opened with the same
+// shard count and intents. It reports whether s will resume.
+func (b *Bot) restoreSession(s *discordgo.Session) bool {
+	if !b.resumable() {
+		return false
+	}
+	window := b.currentConfig().Discord.Sharding.ResumeWindow
+
+	saved, err := b.db.TakeGatewaySession(s.ShardID)
+	if err != nil {
+		b.logger.Error("Failed to load gateway session", "shard", s.ShardID, "error", err)
+		return false
+	}
+	if saved == nil || time.Since(saved.SavedAt) > window ||
+		saved.ShardCount != s.ShardCount || saved.Intents != int(s.Identify.Intents) {
+		return false
+	}
+
+	setResumeState(s, saved.SessionID, saved.Sequence)
+
+	// A resumed session gets no Ready or guild creates, so the state starts
+	// empty. The guilds are loaded as their events arrive rather than all at
+	// once, which would cost two requests per guild on every restart. If
+	// Discord rejects the resume, discordgo identifies and the state fills
+	// up as usual.
+	s.AddHandlerOnce(func(s *discordgo.Session, r *discordgo.Resumed) {
+		go b.loadUser(s)
+	})
+	loader := &guildLoader{bot: b, loading: make(map[string]bool)}
+	s.AddHandler(loader.handle)
+
+	b.logger.Info("Resuming gateway session", "shard", s.ShardID, "sequence", saved.Sequence)
+	return true
+}
+
+// resumable reports whether gateway sessions are saved and resumed, which
+// takes the opt-in setting, a database to keep them in and a discordgo
+// whose session fields are known.
+func (b *Bot) resumable() bool {
+	return b.currentConfig().Discord.Sharding.Resume && b.db != nil && errResume == nil
+}
+
+// loadUser loads the bot user over REST after a resume.
+func (b *Bot) loadUser(s *discordgo.Session) {
+	s.State.RLock()
+	loaded := s.State.User != nil
+	s.State.RUnlock()
+	if loaded {
+		return
+	}
+
+	user, err := s.User("@me")
+	if err != nil {
+		b.logger.Error("Failed to load bot user after resume", "shard", s.ShardID, "error", err)
+		return
+	}
+	s.State.Lock()
+	s.State.User = user
+	s.State.Unlock()
+}
+
+// guildLoader adds the guilds of a resumed shard to its state the first time
+// one of their events arrives. Until then they are missing from the state
+// and from the guild counts.
+type guildLoader struct {
+	bot *Bot
+
+	mu      sync.Mutex
+	loading map[string]bool
+}
+
+func (l *guildLoader) handle(s *discordgo.Session, evt interface{}) {
+	guildID := eventGuildID(evt)
+	if guildID == "" {
+		return
+	}
+	if _, err := s.State.Guild(guildID); err == nil {
+		return
+	}
+
+	l.mu.Lock()
+	if l.loading[guildID] {
+		l.mu.Unlock()
+		return
+	}
+	l.loading[guildID] = true
+	l.mu.Unlock()
+
+	go func() {
+		err := l.bot.loadGuild(s, guildID)
+
+		l.mu.Lock()
+		delete(l.loading, guildID)
+		l.mu.Unlock()
+
+		if err != nil {
+			l.bot.logger.Error("Failed to load guild after resume", "shard", s.ShardID, "guild", guildID, "error", err)
+		}
+	}()
+}
+
+// eventGuildID returns the guild of the events a resumed shard commonly
+// receives first.
+func eventGuildID(evt interface{}) string {
+	switch e := evt.(type) {
+	case *discordgo.InteractionCreate:
+		return e.GuildID
+	case *discordgo.MessageCreate:
+		return e.GuildID
+	case *discordgo.MessageUpdate:
+		return e.GuildID
+	case *discordgo.MessageDelete:
+		return e.GuildID
+	case *discordgo.GuildMemberAdd:
+		return e.GuildID
+	case *discordgo.GuildMemberUpdate:
+		return e.GuildID
+	case *discordgo.GuildMemberRemove:
+		return e.GuildID
+	case *discordgo.VoiceStateUpdate:
+		return e.GuildID
+	case *discordgo.ChannelCreate:
+		return e.GuildID
+	case *discordgo.ChannelUpdate:
+		return e.GuildID
+	case *discordgo.ChannelDelete:
+		return e.GuildID
+	case *discordgo.GuildBanAdd:
+		return e.GuildID
+	case *discordgo.GuildBanRemove:
+		return e.GuildID
+	case *discordgo.MessageReactionAdd:
+		return e.GuildID
+	}
+	return ""
+}
+
+func (b *Bot) loadGuild(s *discordgo.Session, guildID string) error {
+	g, err := s.GuildWithCounts(guildID)
+	if err != nil {
+		return err
+	}
+	channels, err := s.GuildChannels(guildID)
+	if err != nil {
+		return err
+	}
+	g.Channels = channels
+	g.MemberCount = g.ApproximateMemberCount
+
+	if err := s.State.GuildAdd(g); err != nil {
+		return err
+	}
+	b.auditHandler.HandleGuildCreate(s, &discordgo.GuildCreate{Guild: g})
+	return nil
+}
+
+// resumeVersion is the discordgo release whose unexported session fields
+// resumeFields was checked against. Any other release disables resuming
+// until the fields are checked again and this is updated.
+const resumeVersion = "0.28.1"
+
+// discordgo keeps the session ID and sequence number unexported, and offers
+// no way to resume a session in a new process, so they are reached through
+// reflection. errResume is checked once at startup: with another discordgo
+// release, or if the fields were renamed or retyped, shards identify as
+// usual.
+var errResume = checkResumeFields(discordgo.VERSION)
+
+func checkResumeFields(version string) error {
+	if version != resumeVersion {
+		return fmt.Errorf("discordgo %s is not %s, whose session fields are known", version, resumeVersion)
+	}
+	t := reflect.TypeOf(discordgo.Session{})
+	if f, ok := t.FieldByName("sessionID"); !ok || f.Type.Kind() != reflect.String {
+		return errors.New("discordgo.Session has no sessionID string field")
+	}
+	if f, ok := t.FieldByName("sequence"); !ok || f.Type != reflect.TypeOf((*int64)(nil)) {
+		return errors.New("discordgo.Session has no sequence *int64 field")
+	}
+	return nil
+}
+
+func resumeState(s *discordgo.Session) (string, int64) {
+	sessionID, sequence := resumeFields(s)
+	seq := *(**int64)(sequence)
+	if seq == nil {
+		return "", 0
+	}
+	return *(*string)(sessionID), atomic.LoadInt64(seq)
+}
+
+func setResumeState(s *discordgo.Session, sessionID string, sequence int64) {
+	id, seq := resumeFields(s)
+	*(*string)(id) = sessionID
+	ptr := (**int64)(seq)
+	if *ptr == nil {
+		*ptr = new(int64)
+	}
+	atomic.StoreInt64(*ptr, sequence)
+}
+
+// resumeFields must only be called when errResume is nil.
+func resumeFields(s *discordgo.Session) (sessionID, sequence unsafe.Pointer) {
+	v := reflect.ValueOf(s).Elem()
+	id := v.FieldByName("sessionID")
+	seq := v.FieldByName("sequence")
+	return unsafe.Pointer(id.UnsafeAddr()), unsafe.Pointer(seq.UnsafeAddr())
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/database"
+)
+
+// Resuming writes unexported discordgo fields, so it is pinned to the
+// release they were checked against. A discordgo upgrade fails this first,
+// and resuming stays disabled until the fields are checked and
+// resumeVersion is updated.
+func TestResumeFields(t *testing.T) {
+	if errResume != nil {
+		t.Fatalf("resuming is unsupported: %v", errResume)
+	}
+
+	s, err := discordgo.New("Bot token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id, seq := resumeState(s); id != "" || seq != 0 {
+		t.Fatalf("resumeState() of a new session = %q, %d", id, seq)
+	}
+
+	setResumeState(s, "session", 42)
+	if id, seq := resumeState(s); id != "session" || seq != 42 {
+		t.Fatalf("resumeState() = %q, %d, want session, 42", id, seq)
+	}
+}
+
+func TestResumeFieldsRejectsOtherReleases(t *testing.T) {
+	if err := checkResumeFields("0.29.0"); err == nil {
+		t.Fatal("checkResumeFields() accepted an unchecked discordgo release")
+	}
+}
+
+func TestResumeIsOptIn(t *testing.T) {
+	var cfg config.Config
+	b := &Bot{db: &database.MongoDB{}}
+	b.config.Store(&cfg)
+	if b.resumable() {
+		t.Fatal("sessions are resumed without discord.sharding.resume")
+	}
+
+	cfg.Discord.Sharding.Resume = true
+	if !b.resumable() {
+		t.Fatal("sessions are not resumed with discord.sharding.resume")
+	}
+}
@@ -0,0 +1,360 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/logger"
+)
+
+const (
+	// identifyInterval is how long Discord requires between two identifies
+	// in the same rate limit bucket.
+	identifyInterval = 5 * time.Second
+
+	// readyTimeout is how long a shard may go without reaching Ready, and
+	// heartbeatTimeout how long without a heartbeat ack, before it is
+	// restarted. Discord heartbeats roughly every 41 seconds.
+	readyTimeout     = 2 * time.Minute
+	heartbeatTimeout = 2 * time.Minute
+
+	restartBackoffMin = 5 * time.Second
+	restartBackoffMax = 5 * time.Minute
+)
+
+type ShardState string
+
+const (
+	ShardConnecting   ShardState = "connecting"
+	ShardReady        ShardState = "ready"
+	ShardDisconnected ShardState = "disconnected"
+	ShardRestarting   ShardState = "restarting"
+)
+
+// ShardStatus is a snapshot of the health of one shard.
+type ShardStatus struct {
+	ID        int        `json:"id"`
+	State     ShardState `json:"state"`
+	Guilds    int        `json:"guilds"`
+	LatencyMS int64      `json:"latency_ms"`
+	LastEvent time.Time  `json:"last_event"`
+	Restarts  int        `json:"restarts"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// GatewayInfo returns the recommended shard count and the session start
+// limits of the bot.
+func GatewayInfo(token string) (*discordgo.GatewayBotResponse, error) {
+	session, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return nil, err
+	}
+	gateway, err := session.GatewayBot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query gateway: %v", err)
+	}
+	if gateway.SessionStartLimit.MaxConcurrency < 1 {
+		gateway.SessionStartLimit.MaxConcurrency = 1
+	}
+	return gateway, nil
+}
+
+// IdentifyGate decides when a shard may identify.
+type IdentifyGate interface {
+	// Wait blocks until the shard may identify.
+	Wait(shardID int)
+}
+
+// IdentifyLimiter spaces out identifies. Shards share a bucket when their
+// IDs are equal modulo max_concurrency, and each bucket allows one identify
+// every identifyInterval.
+type IdentifyLimiter struct {
+	mu          sync.Mutex
+	concurrency int
+	next        map[int]time.Time
+}
+
+func NewIdentifyLimiter() *IdentifyLimiter {
+	return &IdentifyLimiter{
+		concurrency: 1,
+		next:        make(map[int]time.Time),
+	}
+}
+
+func (l *IdentifyLimiter) SetConcurrency(concurrency int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	l.concurrency = concurrency
+}
+
+func (l *IdentifyLimiter) Wait(shardID int) {
+	l.mu.Lock()
+	bucket := shardID % l.concurrency
+	at := l.next[bucket]
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	l.next[bucket] = at.Add(identifyInterval)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(at))
+}
+
+// shardMonitor tracks the health of one shard session.
+type shardMonitor struct {
+	session *discordgo.Session
+
+	mu          sync.Mutex
+	state       ShardState
+	since       time.Time
+	lastEvent   time.Time
+	restarts    int
+	failures    int
+	nextRestart time.Time
+	restarting  bool
+	stopped     bool
+	lastError   string
+}
+
+func (m *shardMonitor) setState(state ShardState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state != state {
+		m.state = state
+		m.since = time.Now()
+	}
+}
+
+// observe is attached to the shard's session and sees every event.
+func (m *shardMonitor) observe(s *discordgo.Session, evt interface{}) {
+	m.mu.Lock()
+	m.lastEvent = time.Now()
+	m.mu.Unlock()
+
+	switch evt.(type) {
+	case *discordgo.Connect:
+		m.setState(ShardConnecting)
+	case *discordgo.Ready, *discordgo.Resumed:
+		m.setState(ShardReady)
+		m.mu.Lock()
+		m.failures = 0
+		m.lastError = ""
+		m.mu.Unlock()
+	case *discordgo.Disconnect:
+		m.setState(ShardDisconnected)
+	}
+}
+
+// supervisor watches the shards and restarts the ones that disconnected,
+// stopped reaching Ready, stopped receiving heartbeat acks or, when
+// EventTimeout is set, stopped receiving events. Restarts of a failing
+// shard back off exponentially. The supervisor is the only one reopening
+// its shards: discordgo's own reconnect is turned off, since it would race
+// the restarts and open a second connection.
+type supervisor struct {
+	logger   *logger.Logger
+	identify IdentifyGate
+	// interval and eventTimeout hold time.Durations and follow config
+	// reloads.
+	interval     atomic.Int64
+	eventTimeout atomic.Int64
+
+	mu     sync.RWMutex
+	shards map[int]*shardMonitor
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func newSupervisor(cfg *config.Config, l *logger.Logger, identify IdentifyGate) *supervisor {
+	sv := &supervisor{
+		logger:   l.Named("shards"),
+		identify: identify,
+		shards:   make(map[int]*shardMonitor),
+		stop:     make(chan struct{}),
+	}
+	sv.setConfig(cfg)
+	return sv
+}
+
+// setConfig applies the health check settings of cfg.
+func (sv *supervisor) setConfig(cfg *config.Config) {
+	sv.interval.Store(int64(cfg.Discord.Sharding.HealthCheckInterval))
+	sv.eventTimeout.Store(int64(cfg.Discord.Sharding.EventTimeout))
+}
+
+// watch starts tracking session. It must be called before the session is
+// opened so the first events are seen.
+func (sv *supervisor) watch(session *discordgo.Session) {
+	m := &shardMonitor{
+		session:   session,
+		state:     ShardConnecting,
+		since:     time.Now(),
+		lastEvent: time.Now(),
+	}
+	session.ShouldReconnectOnError = false
+	session.AddHandler(m.observe)
+	// Handlers run concurrently, so this one records the disconnect itself
+	// rather than relying on observe having done so.
+	session.AddHandler(func(s *discordgo.Session, d *discordgo.Disconnect) {
+		m.setState(ShardDisconnected)
+		sv.check(m)
+	})
+
+	sv.mu.Lock()
+	sv.shards[session.ShardID] = m
+	sv.mu.Unlock()
+}
+
+func (sv *supervisor) run() {
+	interval := time.Duration(sv.interval.Load())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sv.stop:
+			return
+		case <-ticker.C:
+			sv.mu.RLock()
+			for _, m := range sv.shards {
+				sv.check(m)
+			}
+			sv.mu.RUnlock()
+
+			if next := time.Duration(sv.interval.Load()); next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
+		}
+	}
+}
+
+// reset stops watching every shard, e.g. before the bot closes them.
+func (sv *supervisor) reset() {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+
+	for _, m := range sv.shards {
+		m.mu.Lock()
+		m.stopped = true
+		m.mu.Unlock()
+	}
+	sv.shards = make(map[int]*shardMonitor)
+}
+
+func (sv *supervisor) shutdown() {
+	sv.stopOnce.Do(func() { close(sv.stop) })
+}
+
+func (sv *supervisor) check(m *shardMonitor) {
+	m.session.RLock()
+	lastAck := m.session.LastHeartbeatAck
+	m.session.RUnlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.restarting || time.Now().Before(m.nextRestart) {
+		return
+	}
+
+	eventTimeout := time.Duration(sv.eventTimeout.Load())
+	var reason string
+	switch {
+	case m.stopped:
+		return
+	case m.state == ShardDisconnected:
+		reason = "disconnected"
+	case m.state != ShardReady && time.Since(m.since) > readyTimeout:
+		reason = fmt.Sprintf("%s for %s", m.state, time.Since(m.since).Round(time.Second))
+	case m.state == ShardReady && !lastAck.IsZero() && time.Since(lastAck) > heartbeatTimeout:
+		reason = fmt.Sprintf("no heartbeat ack for %s", time.Since(lastAck).Round(time.Second))
+	case m.state == ShardReady && eventTimeout > 0 && time.Since(m.lastEvent) > eventTimeout:
+		reason = fmt.Sprintf("no events for %s", time.Since(m.lastEvent).Round(time.Second))
+	default:
+		return
+	}
+
+	backoff := restartBackoffMin << m.failures
+	if backoff > restartBackoffMax || backoff <= 0 {
+		backoff = restartBackoffMax
+	}
+	m.failures++
+	m.restarts++
+	m.restarting = true
+	m.nextRestart = time.Now().Add(backoff)
+	m.state = ShardRestarting
+	m.since = time.Now()
+
+	sv.logger.Warn("Restarting shard", "shard", m.session.ShardID, "reason", reason)
+	go sv.restart(m)
+}
+
+func (sv *supervisor) restart(m *shardMonitor) {
+	defer func() {
+		m.mu.Lock()
+		m.restarting = false
+		m.mu.Unlock()
+	}()
+
+	// A disconnected shard has no connection left to close.
+	if err := m.session.Close(); err != nil && !errors.Is(err, discordgo.ErrWSNotFound) {
+		sv.logger.Error("Failed to close shard", "shard", m.session.ShardID, "error", err)
+	}
+
+	sv.identify.Wait(m.session.ShardID)
+
+	m.mu.Lock()
+	stopped := m.stopped
+	m.mu.Unlock()
+	if stopped {
+		return
+	}
+
+	if err := m.session.Open(); err != nil {
+		sv.logger.Error("Failed to reopen shard", "shard", m.session.ShardID, "error", err)
+		m.mu.Lock()
+		m.lastError = err.Error()
+		m.state = ShardDisconnected
+		m.since = time.Now()
+		m.mu.Unlock()
+	}
+}
+
+// status returns a snapshot of every shard, ordered by shard ID.
+func (sv *supervisor) status() []ShardStatus {
+	sv.mu.RLock()
+	defer sv.mu.RUnlock()
+
+	statuses := make([]ShardStatus, 0, len(sv.shards))
+	for id, m := range sv.shards {
+		m.session.State.RLock()
+		guilds := len(m.session.State.Guilds)
+		m.session.State.RUnlock()
+
+		m.mu.Lock()
+		statuses = append(statuses, ShardStatus{
+			ID:        id,
+			State:     m.state,
+			Guilds:    guilds,
+			LatencyMS: m.session.HeartbeatLatency().Milliseconds(),
+			LastEvent: m.lastEvent,
+			Restarts:  m.restarts,
+			LastError: m.lastError,
+		})
+		m.mu.Unlock()
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].ID < statuses[j].ID })
+	return statuses
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/logger"
+)
+
+type offline struct{}
+
+func (offline) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("offline")
+}
+
+// countingGate counts the identifies of the restarted shards.
+type countingGate struct {
+	mu    sync.Mutex
+	waits int
+}
+
+func (g *countingGate) Wait(shardID int) {
+	g.mu.Lock()
+	g.waits++
+	g.mu.Unlock()
+}
+
+func (g *countingGate) count() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.waits
+}
+
+func TestSupervisorRestartsDisconnectedShards(t *testing.T) {
+	var cfg config.Config
+	cfg.Logger.File = filepath.Join(t.TempDir(), "bot.log")
+	cfg.Logger.Level = "error"
+	cfg.Discord.Sharding.HealthCheckInterval = time.Hour
+
+	gate := &countingGate{}
+	sv := newSupervisor(&cfg, logger.New(&cfg), gate)
+
+	session, err := discordgo.New("Bot token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Client = &http.Client{Transport: offline{}}
+	sv.watch(session)
+
+	if session.ShouldReconnectOnError {
+		t.Fatal("discordgo still reconnects supervised shards itself")
+	}
+
+	m := sv.shards[session.ShardID]
+	m.setState(ShardDisconnected)
+	sv.check(m)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.mu.Lock()
+		restarting, lastError := m.restarting, m.lastError
+		m.mu.Unlock()
+		if !restarting && lastError != "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the restart")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The failed restart backs off instead of reopening right away.
+	sv.check(m)
+	if n := gate.count(); n != 1 {
+		t.Errorf("shard identified %d times, want 1", n)
+	}
+
+	// Stopped shards are left alone.
+	sv.reset()
+	m.mu.Lock()
+	m.nextRestart = time.Time{}
+	m.mu.Unlock()
+	sv.check(m)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.restarting {
+		t.Error("stopped shard was restarted")
+	}
+}
@@ -0,0 +1,367 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/database"
+	"github.com/kevinfinalboss/Void/internal/logger"
+	"github.com/kevinfinalboss/Void/internal/models"
+)
+
+// MessageCache keeps the recent messages of every guild so edits and deletes
+// can be shown with their previous content, and remembers the last deleted
+// messages of each channel for /snipe. DMs are not cached.
+//
+// The cache is bounded both per guild and in total, evicting the least
+// recently used messages first, and entries expire after the configured
+// TTL. Expired entries are dropped when they are read or reach the end of
+// the LRU. With persistence enabled, messages are also stored in MongoDB,
+// which answers for messages that were evicted or predate a restart.
+type MessageCache struct {
+	db      *database.MongoDB
+	logger  *logger.Logger
+	persist bool
+
+	mu           sync.Mutex
+	maxMessages  int
+	maxPerGuild  int
+	maxContent   int
+	snipeHistory int
+	ttl          time.Duration
+	lru          *list.List // every entry, most recently used first
+	guilds       map[string]*guildMessages
+}
+
+type guildMessages struct {
+	lru     *list.List
+	entries map[string]*entry
+	// deleted holds the last deleted messages of each channel, newest first.
+	deleted map[string][]*models.CachedMessage
+}
+
+type entry struct {
+	message *models.CachedMessage
+	expires time.Time
+	global  *list.Element
+	local   *list.Element
+}
+
+func NewMessageCache(cfg *config.Config, db *database.MongoDB, l *logger.Logger) (*MessageCache, error) {
+	settings := cfg.Cache.Messages
+
+	c := &MessageCache{
+		db:           db,
+		logger:       l.Named("cache"),
+		maxMessages:  settings.MaxMessages,
+		maxPerGuild:  settings.MaxPerGuild,
+		maxContent:   settings.MaxContentLength,
+		snipeHistory: settings.SnipeHistory,
+		ttl:          settings.TTL,
+		persist:      settings.Persist && db != nil,
+		lru:          list.New(),
+		guilds:       make(map[string]*guildMessages),
+	}
+
+	if c.persist {
+		if err := db.EnsureMessageIndexes(settings.PersistWindow); err != nil {
+			return nil, fmt.Errorf("failed to create message indexes: %v", err)
+		}
+	}
+
+	return c, nil
+}
+
+// ApplyConfig applies the limits and TTL of a reloaded configuration,
+// evicting the messages over the new limits. Cached messages keep the expiry
+// they were stored with, and persistence is only set up at startup. It is
+// meant to be subscribed to the config.Store.
+func (c *MessageCache) ApplyConfig(old, cfg *config.Config) {
+	settings := cfg.Cache.Messages
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxMessages = settings.MaxMessages
+	c.maxPerGuild = settings.MaxPerGuild
+	c.maxContent = settings.MaxContentLength
+	c.snipeHistory = settings.SnipeHistory
+	c.ttl = settings.TTL
+
+	for _, g := range c.guilds {
+		for g.lru.Len() > c.maxPerGuild {
+			c.remove(g, g.lru.Back().Value.(*entry))
+		}
+	}
+	for c.lru.Len() > c.maxMessages {
+		oldest := c.lru.Back().Value.(*entry)
+		c.remove(c.guilds[oldest.message.GuildID], oldest)
+	}
+}
+
+func (c *MessageCache) HandleMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	c.Add(m.Message)
+}
+
+// Add caches a newly created message.
+func (c *MessageCache) Add(m *discordgo.Message) {
+	if !cacheable(m) {
+		return
+	}
+
+	message := c.snapshot(m)
+
+	c.mu.Lock()
+	c.store(message)
+	c.mu.Unlock()
+
+	c.save(message)
+}
+
+// Update caches the new content of an edited message and returns the
+// snapshot from before the edit, or nil if the message was not cached.
+func (c *MessageCache) Update(m *discordgo.Message) *models.CachedMessage {
+	// Partial updates, such as embeds being unfurled, carry no author and
+	// do not change the content.
+	if !cacheable(m) {
+		return nil
+	}
+
+	before := c.Get(m.GuildID, m.ID)
+
+	message := c.snapshot(m)
+	if before != nil {
+		message.CreatedAt = before.CreatedAt
+	}
+	edited := time.Now()
+	if m.EditedTimestamp != nil {
+		edited = *m.EditedTimestamp
+	}
+	message.EditedAt = &edited
+
+	c.mu.Lock()
+	c.store(message)
+	c.mu.Unlock()
+
+	c.save(message)
+	return before
+}
+
+// Delete removes a deleted message from the cache, records it for /snipe
+// and returns it, or nil if the message was not cached.
+func (c *MessageCache) Delete(guildID, channelID, messageID string) *models.CachedMessage {
+	if guildID == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	var message *models.CachedMessage
+	if g, ok := c.guilds[guildID]; ok {
+		if e, ok := g.entries[messageID]; ok {
+			c.remove(g, e)
+			if time.Now().Before(e.expires) {
+				message = e.message
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	if message == nil {
+		message = c.load(messageID)
+		if message == nil {
+			return nil
+		}
+	}
+
+	deleted := time.Now()
+	message.DeletedAt = &deleted
+
+	c.mu.Lock()
+	g := c.guild(guildID)
+	history := append([]*models.CachedMessage{message}, g.deleted[channelID]...)
+	if len(history) > c.snipeHistory {
+		history = history[:c.snipeHistory]
+	}
+	g.deleted[channelID] = history
+	c.mu.Unlock()
+
+	c.save(message)
+	return copyMessage(message)
+}
+
+// Get returns the cached snapshot of a message, falling back to MongoDB when
+// persistence is enabled, or nil if it is unknown.
+func (c *MessageCache) Get(guildID, messageID string) *models.CachedMessage {
+	c.mu.Lock()
+	if g, ok := c.guilds[guildID]; ok {
+		if e, ok := g.entries[messageID]; ok {
+			if time.Now().Before(e.expires) {
+				c.lru.MoveToFront(e.global)
+				g.lru.MoveToFront(e.local)
+				message := copyMessage(e.message)
+				c.mu.Unlock()
+				return message
+			}
+			c.remove(g, e)
+		}
+	}
+	c.mu.Unlock()
+
+	return c.load(messageID)
+}
+
+// Sniped returns the last deleted messages of a channel, newest first.
+func (c *MessageCache) Sniped(guildID, channelID string) []*models.CachedMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	g, ok := c.guilds[guildID]
+	if !ok {
+		return nil
+	}
+
+	var messages []*models.CachedMessage
+	for _, message := range g.deleted[channelID] {
+		if time.Since(*message.DeletedAt) > c.ttl {
+			break
+		}
+		messages = append(messages, copyMessage(message))
+	}
+	if len(messages) == 0 {
+		delete(g.deleted, channelID)
+	}
+	return messages
+}
+
+// RemoveGuild drops the cached and sniped messages of a guild, e.g. after
+// the bot left it. Persisted messages expire on their own.
+func (c *MessageCache) RemoveGuild(guildID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	g, ok := c.guilds[guildID]
+	if !ok {
+		return
+	}
+	for _, e := range g.entries {
+		c.lru.Remove(e.global)
+	}
+	delete(c.guilds, guildID)
+}
+
+// store inserts or replaces message and evicts entries over the limits.
+// c.mu must be held.
+func (c *MessageCache) store(message *models.CachedMessage) {
+	g := c.guild(message.GuildID)
+	if e, ok := g.entries[message.MessageID]; ok {
+		c.remove(g, e)
+	}
+
+	e := &entry{message: message, expires: time.Now().Add(c.ttl)}
+	e.global = c.lru.PushFront(e)
+	e.local = g.lru.PushFront(e)
+	g.entries[message.MessageID] = e
+
+	for g.lru.Len() > c.maxPerGuild {
+		c.remove(g, g.lru.Back().Value.(*entry))
+	}
+	for c.lru.Len() > 0 {
+		oldest := c.lru.Back().Value.(*entry)
+		if c.lru.Len() <= c.maxMessages && time.Now().Before(oldest.expires) {
+			break
+		}
+		c.remove(c.guilds[oldest.message.GuildID], oldest)
+	}
+}
+
+// remove drops e from both LRU lists. c.mu must be held.
+func (c *MessageCache) remove(g *guildMessages, e *entry) {
+	c.lru.Remove(e.global)
+	g.lru.Remove(e.local)
+	delete(g.entries, e.message.MessageID)
+}
+
+// guild returns the messages of a guild, creating them if needed. c.mu must
+// be held.
+func (c *MessageCache) guild(guildID string) *guildMessages {
+	g, ok := c.guilds[guildID]
+	if !ok {
+		g = &guildMessages{
+			lru:     list.New(),
+			entries: make(map[string]*entry),
+			deleted: make(map[string][]*models.CachedMessage),
+		}
+		c.guilds[guildID] = g
+	}
+	return g
+}
+
+func (c *MessageCache) save(message *models.CachedMessage) {
+	if !c.persist {
+		return
+	}
+	if err := c.db.SaveCachedMessage(message); err != nil {
+		c.logger.Error("Failed to persist message", "message", message.MessageID, "error", err)
+	}
+}
+
+func (c *MessageCache) load(messageID string) *models.CachedMessage {
+	if !c.persist {
+		return nil
+	}
+	message, err := c.db.GetCachedMessage(messageID)
+	if err != nil {
+		c.logger.Error("Failed to load persisted message", "message", messageID, "error", err)
+		return nil
+	}
+	return message
+}
+
+func (c *MessageCache) snapshot(m *discordgo.Message) *models.CachedMessage {
+	c.mu.Lock()
+	maxContent := c.maxContent
+	c.mu.Unlock()
+
+	content := []rune(m.Content)
+	if len(content) > maxContent {
+		content = content[:maxContent]
+	}
+
+	message := &models.CachedMessage{
+		MessageID:    m.ID,
+		GuildID:      m.GuildID,
+		ChannelID:    m.ChannelID,
+		AuthorID:     m.Author.ID,
+		AuthorName:   m.Author.Username,
+		AuthorAvatar: m.Author.Avatar,
+		AuthorBot:    m.Author.Bot,
+		Content:      string(content),
+		CreatedAt:    m.Timestamp,
+	}
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+	for _, a := range m.Attachments {
+		message.Attachments = append(message.Attachments, models.CachedAttachment{
+			Filename:    a.Filename,
+			URL:         a.URL,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+		})
+	}
+	return message
+}
+
+func cacheable(m *discordgo.Message) bool {
+	return m.GuildID != "" && m.Author != nil
+}
+
+func copyMessage(m *models.CachedMessage) *models.CachedMessage {
+	c := *m
+	c.Attachments = append([]models.CachedAttachment(nil), m.Attachments...)
+	return &c
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/logger"
+)
+
+func newTestCache(t *testing.T, maxMessages, maxPerGuild int, ttl time.Duration) *MessageCache {
+	t.Helper()
+
+	var cfg config.Config
+	cfg.Logger.File = filepath.Join(t.TempDir(), "cache.log")
+	cfg.Logger.Level = "error"
+	cfg.Cache.Messages.MaxMessages = maxMessages
+	cfg.Cache.Messages.MaxPerGuild = maxPerGuild
+	cfg.Cache.Messages.MaxContentLength = 10
+	cfg.Cache.Messages.TTL = ttl
+	cfg.Cache.Messages.SnipeHistory = 2
+
+	c, err := NewMessageCache(&cfg, nil, logger.New(&cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func message(guildID, channelID, id, content string) *discordgo.Message {
+	return &discordgo.Message{
+		ID:        id,
+		GuildID:   guildID,
+		ChannelID: channelID,
+		Content:   content,
+		Author:    &discordgo.User{ID: "user", Username: "user"},
+	}
+}
+
+func TestMessageCacheEvictsPerGuild(t *testing.T) {
+	c := newTestCache(t, 100, 2, time.Hour)
+
+	c.Add(message("g", "c", "1", "one"))
+	c.Add(message("g", "c", "2", "two"))
+	c.Get("g", "1") // 1 is now more recently used than 2
+	c.Add(message("g", "c", "3", "three"))
+
+	if c.Get("g", "2") != nil {
+		t.Error("message 2 was not evicted")
+	}
+	for _, id := range []string{"1", "3"} {
+		if c.Get("g", id) == nil {
+			t.Errorf("message %s was evicted", id)
+		}
+	}
+}
+
+func TestMessageCacheEvictsAcrossGuilds(t *testing.T) {
+	c := newTestCache(t, 2, 10, time.Hour)
+
+	c.Add(message("a", "c", "1", "one"))
+	c.Add(message("b", "c", "2", "two"))
+	c.Add(message("a", "c", "3", "three"))
+
+	if c.Get("a", "1") != nil {
+		t.Error("the least recently used message was not evicted")
+	}
+	if c.Get("b", "2") == nil || c.Get("a", "3") == nil {
+		t.Error("recent messages were evicted")
+	}
+}
+
+func TestMessageCacheExpires(t *testing.T) {
+	c := newTestCache(t, 10, 10, 20*time.Millisecond)
+
+	c.Add(message("g", "c", "1", "one"))
+	if c.Get("g", "1") == nil {
+		t.Fatal("message was not cached")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if c.Get("g", "1") != nil {
+		t.Error("expired message was returned")
+	}
+}
+
+func TestMessageCacheUpdateAndDelete(t *testing.T) {
+	c := newTestCache(t, 10, 10, time.Hour)
+
+	c.Add(message("g", "c", "1", "a very long message"))
+	before := c.Update(message("g", "c", "1", "edited"))
+	if before == nil || before.Content != "a very lon" {
+		t.Fatalf("Update() returned %+v, want the truncated original", before)
+	}
+
+	deleted := c.Delete("g", "c", "1")
+	if deleted == nil || deleted.Content != "edited" || deleted.DeletedAt == nil {
+		t.Fatalf("Delete() returned %+v, want the edited message", deleted)
+	}
+	if c.Get("g", "1") != nil {
+		t.Error("deleted message is still cached")
+	}
+
+	c.Add(message("g", "c", "2", "two"))
+	c.Delete("g", "c", "2")
+	c.Add(message("g", "c", "3", "three"))
+	c.Delete("g", "c", "3")
+
+	sniped := c.Sniped("g", "c")
+	if len(sniped) != 2 || sniped[0].MessageID != "3" || sniped[1].MessageID != "2" {
+		t.Errorf("Sniped() returned %d messages, want 3 and 2 newest first", len(sniped))
+	}
+}
+
+func TestMessageCacheSkipsDMs(t *testing.T) {
+	c := newTestCache(t, 10, 10, time.Hour)
+
+	c.Add(message("", "c", "1", "dm"))
+	if c.Delete("", "c", "1") != nil {
+		t.Error("DM was cached")
+	}
+}
+
+func TestMessageCacheRemoveGuild(t *testing.T) {
+	c := newTestCache(t, 2, 10, time.Hour)
+
+	c.Add(message("a", "c", "1", "one"))
+	c.Add(message("a", "c", "2", "two"))
+	c.Delete("a", "c", "2")
+	c.Add(message("b", "c", "3", "three"))
+
+	c.RemoveGuild("a")
+
+	if c.Get("a", "1") != nil || len(c.Sniped("a", "c")) != 0 {
+		t.Error("messages of the removed guild are still cached")
+	}
+	// The removed messages no longer count against the total limit.
+	c.Add(message("b", "c", "4", "four"))
+	if c.Get("b", "3") == nil || c.Get("b", "4") == nil {
+		t.Error("messages of another guild were evicted")
+	}
+}
+
+func TestMessageCacheApplyConfig(t *testing.T) {
+	c := newTestCache(t, 10, 10, time.Hour)
+
+	for _, id := range []string{"1", "2", "3"} {
+		c.Add(message("g", "c", id, "message"))
+	}
+
+	var cfg config.Config
+	cfg.Cache.Messages.MaxMessages = 10
+	cfg.Cache.Messages.MaxPerGuild = 2
+	cfg.Cache.Messages.MaxContentLength = 3
+	cfg.Cache.Messages.TTL = time.Hour
+	cfg.Cache.Messages.SnipeHistory = 1
+	c.ApplyConfig(nil, &cfg)
+
+	if c.Get("g", "1") != nil {
+		t.Error("messages over the new per-guild limit were kept")
+	}
+	c.Add(message("g", "c", "4", "four"))
+	if got := c.Get("g", "4"); got == nil || got.Content != "fou" {
+		t.Errorf("Get() = %+v, want content truncated to the new length", got)
+	}
+}
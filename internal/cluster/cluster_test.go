@@ -0,0 +1,387 @@
+package cluster
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/bot"
+	"github.com/kevinfinalboss/Void/internal/logger"
+)
+
+// fakeShards stands in for the bot of a worker.
+type fakeShards struct {
+	owners *shardOwners
+
+	mu       sync.Mutex
+	shardIDs []int
+	total    int
+}
+
+// shardOwners records which worker has each shard open, counting the shards
+// opened while another worker had them open.
+type shardOwners struct {
+	mu       sync.Mutex
+	owner    map[int]*fakeShards
+	overlaps int
+}
+
+func newShardOwners() *shardOwners {
+	return &shardOwners{owner: make(map[int]*fakeShards)}
+}
+
+func (o *shardOwners) open(f *fakeShards, shardIDs []int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, id := range shardIDs {
+		if owner, ok := o.owner[id]; ok && owner != f {
+			o.overlaps++
+		}
+		o.owner[id] = f
+	}
+}
+
+func (o *shardOwners) close(f *fakeShards) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for id, owner := range o.owner {
+		if owner == f {
+			delete(o.owner, id)
+		}
+	}
+}
+
+func (o *shardOwners) overlapping() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.overlaps
+}
+
+func (f *fakeShards) StartShards(shardIDs []int, totalShards int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.shardIDs = append([]int(nil), shardIDs...)
+	f.total = totalShards
+	if f.owners != nil {
+		f.owners.open(f, shardIDs)
+	}
+	return nil
+}
+
+func (f *fakeShards) StopShards() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.shardIDs = nil
+	if f.owners != nil {
+		f.owners.close(f)
+	}
+	return nil
+}
+
+func (f *fakeShards) ShardStatus() []bot.ShardStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	statuses := make([]bot.ShardStatus, 0, len(f.shardIDs))
+	for _, id := range f.shardIDs {
+		statuses = append(statuses, bot.ShardStatus{ID: id, State: bot.ShardState("connected")})
+	}
+	return statuses
+}
+
+func (f *fakeShards) running() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.shardIDs...)
+}
+
+func testConfig(t *testing.T, workers int) *config.Config {
+	var cfg config.Config
+	dir := t.TempDir()
+	cfg.Logger.File = filepath.Join(dir, "cluster.log")
+	cfg.Logger.Level = "error"
+	cfg.Cluster.Enabled = true
+	cfg.Cluster.Network = "unix"
+	cfg.Cluster.Address = filepath.Join(dir, "cluster.sock")
+	cfg.Cluster.Workers = workers
+	cfg.Cluster.StartupGrace = time.Minute
+	cfg.Cluster.HeartbeatInterval = 20 * time.Millisecond
+	return &cfg
+}
+
+// waitFor polls cond until it holds or the timeout expires.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// covers reports whether the workers run every shard exactly once.
+func covers(fakes []*fakeShards, totalShards int) bool {
+	seen := make(map[int]int)
+	for _, f := range fakes {
+		for _, id := range f.running() {
+			seen[id]++
+		}
+	}
+	if len(seen) != totalShards {
+		return false
+	}
+	for id := 0; id < totalShards; id++ {
+		if seen[id] != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestClusterAssignsAndRebalancesShards(t *testing.T) {
+	const totalShards = 8
+
+	cfg := testConfig(t, 3)
+	l := logger.New(cfg)
+
+	c := NewCoordinator(cfg, l)
+	if err := c.listen(totalShards); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	owners := newShardOwners()
+	fakes := make([]*fakeShards, 3)
+	workers := make([]*Worker, 3)
+	for i := range workers {
+		wcfg := *cfg
+		wcfg.Cluster.WorkerID = fmt.Sprintf("worker-%d", i)
+		fakes[i] = &fakeShards{owners: owners}
+		workers[i] = newWorker(&wcfg, l, fakes[i])
+		if err := workers[i].Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer workers[i].Stop()
+	}
+
+	waitFor(t, "every shard to be assigned", func() bool { return covers(fakes, totalShards) })
+
+	for i, f := range fakes {
+		if n := len(f.running()); n < 2 || n > 3 {
+			t.Errorf("worker %d runs %d shards, want 2 or 3", i, n)
+		}
+		f.mu.Lock()
+		total := f.total
+		f.mu.Unlock()
+		if total != totalShards {
+			t.Errorf("worker %d got %d total shards, want %d", i, total, totalShards)
+		}
+	}
+	// Exactly one worker serves shard 0, so only it registers the commands.
+	if got := fakes[0].running(); len(got) == 0 || got[0] != 0 {
+		t.Errorf("worker-0 runs %v, want the range starting at shard 0", got)
+	}
+
+	// The shards of a worker that stops sending heartbeats move to the
+	// others once the process is gone.
+	workers[2].Stop()
+	fakes[2].StopShards()
+	survivors := fakes[:2]
+	waitFor(t, "the shards of the stopped worker to move", func() bool { return covers(survivors, totalShards) })
+
+	if n := owners.overlapping(); n > 0 {
+		t.Errorf("%d shards were opened while another worker had them open", n)
+	}
+
+	statuses := c.ShardStatus()
+	if len(statuses) != totalShards {
+		t.Fatalf("ShardStatus() returned %d shards, want %d", len(statuses), totalShards)
+	}
+}
+
+func TestRebalanceSplitsContiguousRanges(t *testing.T) {
+	cfg := testConfig(t, 1)
+	c := NewCoordinator(cfg, logger.New(cfg))
+	c.totalShards = 10
+	for _, id := range []string{"c", "a", "b"} {
+		c.workers[id] = &workerState{}
+	}
+
+	c.rebalance()
+
+	want := map[string][]int{
+		"a": {0, 1, 2, 3},
+		"b": {4, 5, 6},
+		"c": {7, 8, 9},
+	}
+	for id, shardIDs := range want {
+		if got := c.workers[id].shardIDs; !sameShards(got, shardIDs) {
+			t.Errorf("worker %s got shards %v, want %v", id, got, shardIDs)
+		}
+	}
+	if c.generation != 1 {
+		t.Errorf("generation = %d, want 1", c.generation)
+	}
+}
+
+// fakeSettings records the guilds whose cached settings were dropped.
+type fakeSettings struct {
+	mu          sync.Mutex
+	changed     func(guildID string)
+	invalidated map[string]bool
+}
+
+func (f *fakeSettings) OnSettingsChanged(fn func(guildID string)) {
+	f.changed = fn
+}
+
+func (f *fakeSettings) InvalidateGuildSettings(guildIDs ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, id := range guildIDs {
+		f.invalidated[id] = true
+	}
+}
+
+func (f *fakeSettings) dropped(guildID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.invalidated[guildID]
+}
+
+func TestClusterSharesSettingsChanges(t *testing.T) {
+	cfg := testConfig(t, 2)
+	l := logger.New(cfg)
+
+	c := NewCoordinator(cfg, l)
+	if err := c.listen(2); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	settings := make([]*fakeSettings, 2)
+	for i := range settings {
+		wcfg := *cfg
+		wcfg.Cluster.WorkerID = fmt.Sprintf("worker-%d", i)
+		settings[i] = &fakeSettings{invalidated: make(map[string]bool)}
+		w := newWorker(&wcfg, l, &fakeShards{})
+		w.setSettingsCache(settings[i])
+		if err := w.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer w.Stop()
+	}
+
+	settings[0].changed("123")
+
+	waitFor(t, "the other worker to drop the settings", func() bool { return settings[1].dropped("123") })
+	time.Sleep(5 * cfg.Cluster.HeartbeatInterval)
+	if settings[0].dropped("123") {
+		t.Error("the worker that changed the settings was told to drop them")
+	}
+}
+
+func TestCoordinatorHoldsShardsUntilReleased(t *testing.T) {
+	cfg := testConfig(t, 1)
+	c := NewCoordinator(cfg, logger.New(cfg))
+	c.totalShards = 4
+	c.startedAt = time.Now()
+
+	running := func(ids ...int) []bot.ShardStatus {
+		statuses := make([]bot.ShardStatus, 0, len(ids))
+		for _, id := range ids {
+			statuses = append(statuses, bot.ShardStatus{ID: id})
+		}
+		return statuses
+	}
+
+	a := c.join("a", nil, -1)
+	if !sameShards(a.ShardIDs, []int{0, 1, 2, 3}) {
+		t.Fatalf("a got %v, want every shard", a.ShardIDs)
+	}
+	c.join("a", running(0, 1, 2, 3), a.Generation)
+
+	// b takes half of the shards, but a still runs them.
+	b := c.join("b", nil, -1)
+	if b.Generation == a.Generation || len(b.ShardIDs) != 0 {
+		t.Fatalf("b got %v in generation %d, want nothing until a releases its shards", b.ShardIDs, b.Generation)
+	}
+
+	a = c.join("a", running(0, 1, 2, 3), a.Generation)
+	if !sameShards(a.ShardIDs, []int{0, 1}) {
+		t.Fatalf("a got %v, want 0 and 1", a.ShardIDs)
+	}
+	if b = c.join("b", running(), -1); len(b.ShardIDs) != 0 {
+		t.Fatalf("b got %v before a applied the new generation", b.ShardIDs)
+	}
+
+	c.join("a", running(0, 1), a.Generation)
+	if b = c.join("b", running(), -1); !sameShards(b.ShardIDs, []int{2, 3}) {
+		t.Fatalf("b got %v, want 2 and 3 once a released them", b.ShardIDs)
+	}
+}
+
+func TestCoordinatorHoldsShardsOfExpiredWorkers(t *testing.T) {
+	cfg := testConfig(t, 1)
+	c := NewCoordinator(cfg, logger.New(cfg))
+	c.totalShards = 2
+	c.startedAt = time.Now()
+
+	a := c.join("a", nil, -1)
+	c.join("a", []bot.ShardStatus{{ID: 0}, {ID: 1}}, a.Generation)
+	c.join("b", nil, -1)
+
+	window := missedHeartbeats * cfg.Cluster.HeartbeatInterval
+	c.mu.Lock()
+	c.workers["a"].lastSeen = time.Now().Add(-window - time.Millisecond)
+	c.mu.Unlock()
+	c.expire()
+
+	// a may still be closing its shards, so b waits for it to be removed.
+	if b := c.join("b", nil, -1); len(b.ShardIDs) != 0 {
+		t.Fatalf("b got %v while the expired worker may still run them", b.ShardIDs)
+	}
+	if n := len(c.ShardStatus()); n != 2 {
+		t.Fatalf("ShardStatus() returned %d shards, want 2", n)
+	}
+	for _, shard := range c.ShardStatus() {
+		if shard.State != shardUnassigned {
+			t.Errorf("shard %d of the expired worker reported as %s", shard.ID, shard.State)
+		}
+	}
+
+	c.mu.Lock()
+	c.workers["a"].lastSeen = time.Now().Add(-2*window - time.Millisecond)
+	c.mu.Unlock()
+	c.expire()
+
+	if b := c.join("b", nil, -1); !sameShards(b.ShardIDs, []int{0, 1}) {
+		t.Fatalf("b got %v, want every shard once the expired worker is removed", b.ShardIDs)
+	}
+}
+
+func TestWorkerStopsShardsWithoutCoordinator(t *testing.T) {
+	cfg := testConfig(t, 1)
+	l := logger.New(cfg)
+
+	c := NewCoordinator(cfg, l)
+	if err := c.listen(2); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeShards{}
+	w := newWorker(cfg, l, fake)
+	if err := w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	waitFor(t, "the shards to be assigned", func() bool { return len(fake.running()) == 2 })
+
+	c.Stop()
+	waitFor(t, "the worker to stop its shards", func() bool { return len(fake.running()) == 0 })
+}
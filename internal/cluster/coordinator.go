@@ -0,0 +1,478 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/bot"
+	"github.com/kevinfinalboss/Void/internal/logger"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+// missedHeartbeats is how many heartbeats a worker may miss before it is
+// expired and its shards are reassigned. Workers stop their shards sooner,
+// and the reassigned shards are held for as long again before they are
+// handed out, so a worker that lost the coordinator never overlaps the one
+// taking over.
+const missedHeartbeats = 3
+
+// Coordinator splits the shards of the bot into contiguous ranges, one per
+// connected worker, and rebalances them whenever a worker joins or stops
+// sending heartbeats. A shard moving between workers is only handed to the
+// new one once the previous one acknowledged the generation that releases
+// it, so no shard is opened twice. The coordinator also paces the identifies
+// of every worker and answers the queries that span the whole cluster. It
+// opens no shards itself.
+type Coordinator struct {
+	config  *config.Config
+	logger  *logger.Logger
+	limiter *bot.IdentifyLimiter
+
+	mu          sync.Mutex
+	totalShards int
+	generation  int
+	workers     map[string]*workerState
+	startedAt   time.Time
+
+	listener net.Listener
+	conns    map[net.Conn]bool
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+type workerState struct {
+	lastSeen time.Time
+	// expired is set once the worker missed too many heartbeats. Its
+	// shards are reassigned, but its grants keep them from being handed
+	// out until it is removed.
+	expired bool
+	// shardIDs are the shards the worker should run, some of which may be
+	// held while their previous owner releases them.
+	shardIDs []int
+	shards   []bot.ShardStatus
+	// acked is the generation of the last assignment the worker applied.
+	acked int
+	// grants are the assignments sent to the worker since acked. Until it
+	// acknowledges a later generation, it may run any of their shards.
+	grants []grant
+	// changedSettings holds the guilds whose settings changed since the
+	// worker's last heartbeat.
+	changedSettings map[string]bool
+}
+
+type grant struct {
+	generation int
+	shardIDs   []int
+}
+
+// claims returns the shards the worker may be running: those of the grants
+// it has not released and those it last reported.
+func (w *workerState) claims() map[int]bool {
+	claimed := make(map[int]bool)
+	for _, g := range w.grants {
+		for _, id := range g.shardIDs {
+			claimed[id] = true
+		}
+	}
+	for _, shard := range w.shards {
+		claimed[shard.ID] = true
+	}
+	return claimed
+}
+
+func NewCoordinator(cfg *config.Config, l *logger.Logger) *Coordinator {
+	return &Coordinator{
+		config:  cfg,
+		logger:  l.Named("cluster"),
+		limiter: bot.NewIdentifyLimiter(),
+		workers: make(map[string]*workerState),
+		conns:   make(map[net.Conn]bool),
+		stop:    make(chan struct{}),
+	}
+}
+
+// Start resolves the shard count and starts accepting workers.
+func (c *Coordinator) Start() error {
+	gateway, err := bot.GatewayInfo(c.config.Discord.Token)
+	if err != nil {
+		return err
+	}
+
+	totalShards := c.config.Discord.Sharding.TotalShards
+	if totalShards <= 0 {
+		totalShards = gateway.Shards
+	}
+	if totalShards <= 0 {
+		return errors.New("invalid shard count")
+	}
+	c.limiter.SetConcurrency(gateway.SessionStartLimit.MaxConcurrency)
+
+	return c.listen(totalShards)
+}
+
+// listen starts accepting workers for totalShards shards.
+func (c *Coordinator) listen(totalShards int) error {
+	cluster := c.config.Cluster
+	if cluster.Network == "unix" {
+		if err := os.Remove(cluster.Address); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale socket: %v", err)
+		}
+	}
+	listener, err := net.Listen(cluster.Network, cluster.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s %s: %v", cluster.Network, cluster.Address, err)
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName(serviceName, &service{c: c}); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to register coordinator service: %v", err)
+	}
+
+	c.mu.Lock()
+	c.totalShards = totalShards
+	c.startedAt = time.Now()
+	c.listener = listener
+	c.mu.Unlock()
+
+	go c.accept(server, listener)
+	go c.expireLoop()
+
+	c.logger.Info("Coordinator listening", "network", cluster.Network, "address", cluster.Address,
+		"total_shards", totalShards)
+	return nil
+}
+
+// accept serves the workers connecting to listener until it is closed.
+func (c *Coordinator) accept(server *rpc.Server, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		c.mu.Lock()
+		c.conns[conn] = true
+		c.mu.Unlock()
+
+		go func() {
+			server.ServeConn(conn)
+
+			c.mu.Lock()
+			delete(c.conns, conn)
+			c.mu.Unlock()
+		}()
+	}
+}
+
+// Stop stops accepting workers and closes the connections of the current
+// ones, which stop their shards once they miss enough heartbeats.
+func (c *Coordinator) Stop() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for conn := range c.conns {
+		conn.Close()
+	}
+	if c.listener == nil {
+		return nil
+	}
+	return c.listener.Close()
+}
+
+// ShardStatus returns the status of every shard as last reported by the
+// workers. Shards no worker runs are reported as unassigned.
+func (c *Coordinator) ShardStatus() []bot.ShardStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reported := make(map[int]bot.ShardStatus)
+	for _, w := range c.workers {
+		if w.expired {
+			continue
+		}
+		for _, shard := range w.shards {
+			reported[shard.ID] = shard
+		}
+	}
+
+	statuses := make([]bot.ShardStatus, 0, c.totalShards)
+	for id := 0; id < c.totalShards; id++ {
+		shard, ok := reported[id]
+		if !ok {
+			shard = bot.ShardStatus{ID: id, State: shardUnassigned}
+		}
+		statuses = append(statuses, shard)
+	}
+	return statuses
+}
+
+func (c *Coordinator) stats() types.ClusterStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var stats types.ClusterStats
+	for _, w := range c.workers {
+		if w.expired {
+			continue
+		}
+		stats.Processes++
+		stats.Shards += len(w.shards)
+		for _, shard := range w.shards {
+			stats.Guilds += shard.Guilds
+		}
+	}
+	return stats
+}
+
+// join records a heartbeat or registration of a worker and returns its
+// assignment. acked is the generation the worker last applied, or -1 if it
+// did not say. Unknown and expired workers, including those known to a
+// previous coordinator, trigger a rebalance.
+func (c *Coordinator) join(workerID string, shards []bot.ShardStatus, acked int) Assignment {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w, ok := c.workers[workerID]
+	switch {
+	case !ok:
+		w = &workerState{}
+		c.workers[workerID] = w
+		c.logger.Info("Worker joined the cluster", "worker", workerID)
+	case w.expired:
+		w.expired = false
+		c.logger.Info("Worker rejoined the cluster", "worker", workerID)
+	}
+	w.lastSeen = time.Now()
+	if shards != nil {
+		w.shards = shards
+	}
+	// Generations of a previous coordinator say nothing about this one's
+	// grants.
+	if acked > w.acked && acked <= c.generation {
+		w.acked = acked
+		w.release()
+	}
+
+	if (!ok || !w.assigned()) && c.ready() {
+		c.rebalance()
+	}
+
+	assignment := Assignment{
+		Generation:  c.generation,
+		ShardIDs:    c.grantable(workerID, w),
+		TotalShards: c.totalShards,
+	}
+	w.grant(assignment.Generation, assignment.ShardIDs)
+	for guildID := range w.changedSettings {
+		assignment.ChangedSettings = append(assignment.ChangedSettings, guildID)
+	}
+	w.changedSettings = nil
+	return assignment
+}
+
+// settingsChanged queues guildID for every worker but the one that changed
+// its settings.
+func (c *Coordinator) settingsChanged(workerID, guildID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, w := range c.workers {
+		if id == workerID {
+			continue
+		}
+		if w.changedSettings == nil {
+			w.changedSettings = make(map[string]bool)
+		}
+		w.changedSettings[guildID] = true
+	}
+}
+
+// grantable returns the shards of w that no other worker may still be
+// running. c.mu must be held.
+func (c *Coordinator) grantable(workerID string, w *workerState) []int {
+	held := make(map[int]bool)
+	for id, other := range c.workers {
+		if id == workerID {
+			continue
+		}
+		for shardID := range other.claims() {
+			held[shardID] = true
+		}
+	}
+
+	shardIDs := make([]int, 0, len(w.shardIDs))
+	for _, id := range w.shardIDs {
+		if !held[id] {
+			shardIDs = append(shardIDs, id)
+		}
+	}
+	return shardIDs
+}
+
+// grant records the shards sent to the worker with an assignment.
+func (w *workerState) grant(generation int, shardIDs []int) {
+	if n := len(w.grants); n > 0 && w.grants[n-1].generation == generation &&
+		sameShards(w.grants[n-1].shardIDs, shardIDs) {
+		return
+	}
+	w.grants = append(w.grants, grant{generation: generation, shardIDs: shardIDs})
+}
+
+// release forgets the grants older than the generation the worker
+// acknowledged, whose shards it stopped running.
+func (w *workerState) release() {
+	kept := w.grants[:0]
+	for _, g := range w.grants {
+		if g.generation >= w.acked {
+			kept = append(kept, g)
+		}
+	}
+	w.grants = kept
+}
+
+// assigned reports whether the worker took part in the last rebalance.
+func (w *workerState) assigned() bool {
+	return w.shardIDs != nil
+}
+
+// ready reports whether shards may be assigned: enough workers joined or
+// the startup grace is over. c.mu must be held.
+func (c *Coordinator) ready() bool {
+	return len(c.live()) >= c.config.Cluster.Workers ||
+		time.Since(c.startedAt) > c.config.Cluster.StartupGrace
+}
+
+// live returns the IDs of the workers that are not expired, sorted. c.mu
+// must be held.
+func (c *Coordinator) live() []string {
+	ids := make([]string, 0, len(c.workers))
+	for id, w := range c.workers {
+		if !w.expired {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// rebalance splits the shards into contiguous ranges over the live workers,
+// ordered by ID so the ranges are stable while membership is. c.mu must be
+// held.
+func (c *Coordinator) rebalance() {
+	ids := c.live()
+	for _, w := range c.workers {
+		if w.expired {
+			w.shardIDs = nil
+		}
+	}
+
+	c.generation++
+	next := 0
+	for i, id := range ids {
+		count := c.totalShards / len(ids)
+		if i < c.totalShards%len(ids) {
+			count++
+		}
+
+		shardIDs := make([]int, count)
+		for j := range shardIDs {
+			shardIDs[j] = next + j
+		}
+		next += count
+
+		c.workers[id].shardIDs = shardIDs
+		c.logger.Info("Assigned shards", "worker", id, "shards", count, "generation", c.generation)
+	}
+}
+
+// expireLoop expires workers that stopped sending heartbeats and makes the
+// first assignment once the startup grace is over.
+func (c *Coordinator) expireLoop() {
+	ticker := time.NewTicker(c.config.Cluster.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.expire()
+		}
+	}
+}
+
+// expire reassigns the shards of the workers that missed too many
+// heartbeats. They are removed, releasing the shards they may still run,
+// once they missed as many again.
+func (c *Coordinator) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	window := missedHeartbeats * c.config.Cluster.HeartbeatInterval
+	changed := false
+	for id, w := range c.workers {
+		silent := time.Since(w.lastSeen)
+		switch {
+		case silent > 2*window:
+			delete(c.workers, id)
+			c.logger.Info("Released the shards of the expired worker", "worker", id)
+		case silent > window && !w.expired:
+			w.expired = true
+			changed = true
+			c.logger.Warn("Worker stopped responding, reassigning its shards", "worker", id)
+		}
+	}
+	if c.generation == 0 && c.ready() {
+		changed = true
+	}
+	if changed && len(c.live()) > 0 {
+		c.rebalance()
+	}
+}
+
+// service exposes the coordinator to the workers over net/rpc.
+type service struct {
+	c *Coordinator
+}
+
+func (s *service) Register(args RegisterArgs, reply *Assignment) error {
+	*reply = s.c.join(args.WorkerID, nil, -1)
+	return nil
+}
+
+func (s *service) Heartbeat(args HeartbeatArgs, reply *Assignment) error {
+	shards := args.Shards
+	if shards == nil {
+		shards = []bot.ShardStatus{}
+	}
+	*reply = s.c.join(args.WorkerID, shards, args.Generation)
+	return nil
+}
+
+// Identify blocks until the shard may identify, pacing every worker with a
+// single limiter.
+func (s *service) Identify(args IdentifyArgs, reply *bool) error {
+	s.c.limiter.Wait(args.ShardID)
+	*reply = true
+	return nil
+}
+
+func (s *service) SettingsChanged(args SettingsArgs, reply *bool) error {
+	s.c.settingsChanged(args.WorkerID, args.GuildID)
+	*reply = true
+	return nil
+}
+
+func (s *service) Stats(args StatsArgs, reply *types.ClusterStats) error {
+	*reply = s.c.stats()
+	return nil
+}
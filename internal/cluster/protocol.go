@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"github.com/kevinfinalboss/Void/internal/bot"
+)
+
+// serviceName is the name the coordinator registers its RPC methods under.
+const serviceName = "Coordinator"
+
+// shardUnassigned is reported for shards no worker is running.
+const shardUnassigned bot.ShardState = "unassigned"
+
+// fenced is the generation of the empty assignment a worker applies when it
+// lost the coordinator.
+const fenced = -1
+
+// Assignment is the set of shards a worker must run. Generation changes
+// whenever the coordinator rebalances.
+type Assignment struct {
+	Generation  int
+	ShardIDs    []int
+	TotalShards int
+	// ChangedSettings lists the guilds whose settings other workers changed
+	// since the last heartbeat, so their cached copy can be dropped.
+	ChangedSettings []string
+}
+
+type RegisterArgs struct {
+	WorkerID string
+}
+
+type HeartbeatArgs struct {
+	WorkerID string
+	Shards   []bot.ShardStatus
+	// Generation is the generation of the last assignment the worker
+	// applied, which releases the shards it no longer includes.
+	Generation int
+}
+
+type IdentifyArgs struct {
+	WorkerID string
+	ShardID  int
+}
+
+type SettingsArgs struct {
+	WorkerID string
+	GuildID  string
+}
+
+type StatsArgs struct {
+	WorkerID string
+}
+
+func sameShards(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
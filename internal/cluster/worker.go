@@ -0,0 +1,309 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/rpc"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/bot"
+	"github.com/kevinfinalboss/Void/internal/logger"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+// Worker runs the shards the coordinator assigns to it. It reports the
+// health of its shards and the generation it applied with every heartbeat,
+// and restarts them whenever the coordinator rebalances. If the coordinator
+// is unreachable the worker keeps its current shards and retries, until
+// the coordinator may consider it expired: it then stops them, since they
+// are about to be handed to other workers.
+type Worker struct {
+	config   *config.Config
+	logger   *logger.Logger
+	shards   Shards
+	settings SettingsCache
+	id       string
+
+	// fallback paces identifies while the coordinator is unreachable.
+	fallback *bot.IdentifyLimiter
+
+	mu     sync.Mutex
+	client *rpc.Client
+
+	offered     *Assignment
+	assignments chan Assignment
+	current     Assignment
+	// applied is the generation of the last assignment applied.
+	applied atomic.Int64
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// Shards opens and closes the shards assigned to a worker. *bot.Bot
+// implements it.
+type Shards interface {
+	StartShards(shardIDs []int, totalShards int) error
+	StopShards() error
+	ShardStatus() []bot.ShardStatus
+}
+
+// SettingsCache caches guild settings. *database.MongoDB implements it.
+type SettingsCache interface {
+	OnSettingsChanged(fn func(guildID string))
+	InvalidateGuildSettings(guildIDs ...string)
+}
+
+// NewWorker makes b a worker of the cluster. b identifies its shards through
+// the coordinator and answers cluster queries through it, and the guild
+// settings it caches are dropped when another worker changes them.
+func NewWorker(cfg *config.Config, l *logger.Logger, b *bot.Bot) *Worker {
+	w := newWorker(cfg, l, b)
+	b.SetIdentifyGate(w)
+	b.SetCluster(w)
+	if db := b.Database(); db != nil {
+		w.setSettingsCache(db)
+	}
+	return w
+}
+
+func (w *Worker) setSettingsCache(settings SettingsCache) {
+	w.settings = settings
+	settings.OnSettingsChanged(w.settingsChanged)
+}
+
+// settingsChanged tells the coordinator about settings this worker changed.
+// Should it be unreachable, the other workers see the change once their
+// cached copy expires.
+func (w *Worker) settingsChanged(guildID string) {
+	go func() {
+		var ok bool
+		if err := w.call("SettingsChanged", SettingsArgs{WorkerID: w.id, GuildID: guildID}, &ok); err != nil {
+			w.logger.Error("Failed to share settings change", "worker", w.id, "guild", guildID, "error", err)
+		}
+	}()
+}
+
+func newWorker(cfg *config.Config, l *logger.Logger, shards Shards) *Worker {
+	id := cfg.Cluster.WorkerID
+	if id == "" {
+		host, _ := os.Hostname()
+		id = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+
+	return &Worker{
+		config:      cfg,
+		logger:      l.Named("cluster"),
+		shards:      shards,
+		id:          id,
+		fallback:    bot.NewIdentifyLimiter(),
+		assignments: make(chan Assignment, 1),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start registers with the coordinator and starts following its
+// assignments.
+func (w *Worker) Start() error {
+	var assignment Assignment
+	if err := w.call("Register", RegisterArgs{WorkerID: w.id}, &assignment); err != nil {
+		return fmt.Errorf("failed to register with coordinator: %v", err)
+	}
+
+	w.logger.Info("Registered with the coordinator", "worker", w.id)
+	w.offer(assignment)
+
+	go w.applyLoop()
+	go w.heartbeatLoop()
+	return nil
+}
+
+func (w *Worker) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.client != nil {
+		w.client.Close()
+		w.client = nil
+	}
+}
+
+// Wait implements bot.IdentifyGate through the coordinator.
+func (w *Worker) Wait(shardID int) {
+	var ok bool
+	if err := w.call("Identify", IdentifyArgs{WorkerID: w.id, ShardID: shardID}, &ok); err != nil {
+		w.logger.Error("Failed to reserve identify", "worker", w.id, "shard", shardID, "error", err)
+		w.fallback.Wait(shardID)
+	}
+}
+
+// Stats implements types.Cluster with the totals known to the coordinator.
+func (w *Worker) Stats(ctx context.Context) (types.ClusterStats, error) {
+	client, err := w.dial()
+	if err != nil {
+		return types.ClusterStats{}, err
+	}
+
+	var stats types.ClusterStats
+	call := client.Go(serviceName+".Stats", StatsArgs{WorkerID: w.id}, &stats, nil)
+	select {
+	case <-ctx.Done():
+		return types.ClusterStats{}, ctx.Err()
+	case <-call.Done:
+		if call.Error != nil {
+			w.dropClient(client, call.Error)
+			return types.ClusterStats{}, call.Error
+		}
+		return stats, nil
+	}
+}
+
+func (w *Worker) heartbeatLoop() {
+	interval := w.config.Cluster.HeartbeatInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// The coordinator expires workers after missedHeartbeats; stopping a
+	// heartbeat earlier leaves time to close the shards before then.
+	fenceAfter := (missedHeartbeats - 1) * interval
+	accepted := time.Now()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		args := HeartbeatArgs{
+			WorkerID:   w.id,
+			Shards:     w.shards.ShardStatus(),
+			Generation: int(w.applied.Load()),
+		}
+		var assignment Assignment
+		if err := w.call("Heartbeat", args, &assignment); err != nil {
+			w.logger.Error("Failed to reach coordinator", "worker", w.id, "error", err)
+			if time.Since(accepted) > fenceAfter {
+				w.fence()
+			}
+			continue
+		}
+		accepted = time.Now()
+		if len(assignment.ChangedSettings) > 0 && w.settings != nil {
+			w.settings.InvalidateGuildSettings(assignment.ChangedSettings...)
+		}
+		w.offer(assignment)
+	}
+}
+
+// offer queues a new assignment, replacing one that was not applied yet.
+// Applying runs apart from the heartbeats, since opening shards can take
+// longer than the coordinator waits for a heartbeat. Within a generation
+// the shards grow as the coordinator releases the ones other workers held.
+func (w *Worker) offer(a Assignment) {
+	if w.offered != nil && a.Generation == w.offered.Generation && sameShards(a.ShardIDs, w.offered.ShardIDs) {
+		return
+	}
+	w.offered = &a
+
+	select {
+	case <-w.assignments:
+	default:
+	}
+	w.assignments <- a
+}
+
+// fence stops the shards of a worker cut off from the coordinator, which
+// is about to hand them to other workers. The next assignment is applied
+// whatever its generation.
+func (w *Worker) fence() {
+	if w.offered == nil || len(w.offered.ShardIDs) == 0 {
+		return
+	}
+	w.logger.Warn("Lost the coordinator, stopping shards", "worker", w.id)
+	w.offer(Assignment{Generation: fenced})
+}
+
+func (w *Worker) applyLoop() {
+	for {
+		select {
+		case <-w.stop:
+			return
+		case a := <-w.assignments:
+			w.apply(a)
+		}
+	}
+}
+
+func (w *Worker) apply(a Assignment) {
+	if a.Generation != fenced {
+		defer w.applied.Store(int64(a.Generation))
+	}
+	if sameShards(w.current.ShardIDs, a.ShardIDs) && (w.current.TotalShards == a.TotalShards || len(a.ShardIDs) == 0) {
+		w.current = a
+		return
+	}
+
+	w.logger.Info("Switching shards", "worker", w.id, "shards", len(a.ShardIDs),
+		"total_shards", a.TotalShards, "generation", a.Generation)
+
+	if len(w.current.ShardIDs) > 0 {
+		if err := w.shards.StopShards(); err != nil {
+			w.logger.Error("Failed to stop shards", "worker", w.id, "error", err)
+		}
+	}
+	w.current = a
+
+	if len(a.ShardIDs) > 0 {
+		if err := w.shards.StartShards(a.ShardIDs, a.TotalShards); err != nil {
+			w.logger.Error("Failed to start shards", "worker", w.id, "error", err)
+		}
+	}
+}
+
+func (w *Worker) call(method string, args, reply interface{}) error {
+	client, err := w.dial()
+	if err != nil {
+		return err
+	}
+	if err := client.Call(serviceName+"."+method, args, reply); err != nil {
+		w.dropClient(client, err)
+		return err
+	}
+	return nil
+}
+
+func (w *Worker) dial() (*rpc.Client, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.client != nil {
+		return w.client, nil
+	}
+	client, err := rpc.Dial(w.config.Cluster.Network, w.config.Cluster.Address)
+	if err != nil {
+		return nil, err
+	}
+	w.client = client
+	return client, nil
+}
+
+// dropClient discards client after a connection error so the next call
+// redials. Errors returned by the coordinator keep the connection.
+func (w *Worker) dropClient(client *rpc.Client, err error) {
+	if _, ok := err.(rpc.ServerError); ok {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.client == client {
+		w.client.Close()
+		w.client = nil
+	}
+}
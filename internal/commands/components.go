@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/registry"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+// handleComponent routes button, select menu and modal submit interactions
+// to the component registered under the name encoded in their CustomID.
+// CustomIDs that were not built with types.ComponentID are ignored.
+func (h *Handler) handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	id, err := types.ParseComponentID(types.CustomID(i))
+	if err != nil {
+		return
+	}
+
+	component, exists := registry.Components[id.Name]
+	if !exists {
+		return
+	}
+
+	inv := h.newInvocation(s, i, nil)
+
+	if id.Expired() {
+		h.expireComponent(inv)
+		return
+	}
+
+	if id.Owner != "" && id.Owner != inv.UserID() {
+		h.respondEphemeral(inv.Responder, "❌ Apenas quem usou o comando pode interagir com isto.")
+		return
+	}
+
+	if !h.inflight.begin() {
+		h.respondEphemeral(inv.Responder, restartingMessage)
+		return
+	}
+	defer h.inflight.end()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCommandTimeout)
+	defer cancel()
+
+	if err := runComponent(ctx, component, inv, id); err != nil {
+		inv.Logger.Error("Component failed", "error", err)
+
+		message := "Ocorreu um erro ao processar a interação."
+		var userErr *types.UserError
+		if errors.As(err, &userErr) {
+			message = userErr.Message
+		}
+		h.replyError(inv.Responder, false, message)
+	}
+}
+
+func runComponent(ctx context.Context, component *types.Component, inv *types.Invocation, id *types.ComponentID) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic in component %s: %v\n%s", component.Name, r, debug.Stack())
+		}
+	}()
+	return component.Run(ctx, inv, id)
+}
+
+// expireComponent removes the components from the message the stale one
+// belongs to and tells the user to run the command again.
+func (h *Handler) expireComponent(inv *types.Invocation) {
+	const notice = "⌛ Esta interação expirou. Use o comando novamente."
+
+	i := inv.Interaction
+	if i.Type != discordgo.InteractionMessageComponent || i.Message == nil {
+		h.respondEphemeral(inv.Responder, notice)
+		return
+	}
+
+	err := inv.UpdateMessage(&discordgo.InteractionResponseData{
+		Content:    i.Message.Content,
+		Embeds:     i.Message.Embeds,
+		Components: []discordgo.MessageComponent{},
+	})
+	if err != nil {
+		h.respondEphemeral(inv.Responder, notice)
+		return
+	}
+
+	err = inv.Responder.FollowUp(&discordgo.WebhookParams{
+		Content: notice,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		inv.Logger.Error("Failed to send component expiry notice", "error", err)
+	}
+}
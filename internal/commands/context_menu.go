@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+func isContextMenu(cmd *types.Command) bool {
+	return cmd.CommandType == discordgo.UserApplicationCommand ||
+		cmd.CommandType == discordgo.MessageApplicationCommand
+}
+
+// commandKey identifies an executable command. Context-menu names are
+// scoped by type since Discord allows a user and a message command to share
+// a name.
+func commandKey(t discordgo.ApplicationCommandType, path string) string {
+	switch t {
+	case discordgo.UserApplicationCommand:
+		return "user:" + path
+	case discordgo.MessageApplicationCommand:
+		return "message:" + path
+	default:
+		return path
+	}
+}
+
+func interactionKey(i *discordgo.InteractionCreate) string {
+	data := i.ApplicationCommandData()
+	return commandKey(data.CommandType, commandPath(data))
+}
+
+// contextMenuRun adapts the typed handler of a context-menu command to a
+// RunFunc, resolving the target user or message from the interaction.
+func contextMenuRun(cmd *types.Command) types.RunFunc {
+	return func(ctx context.Context, inv *types.Invocation) error {
+		data := inv.Interaction.ApplicationCommandData()
+		if data.Resolved == nil {
+			return fmt.Errorf("context menu command %s without resolved data", cmd.Name)
+		}
+
+		switch cmd.CommandType {
+		case discordgo.UserApplicationCommand:
+			if cmd.RunUser == nil {
+				return fmt.Errorf("user command %s has no handler", cmd.Name)
+			}
+			user := data.Resolved.Users[data.TargetID]
+			if user == nil {
+				return types.NewUserError("Não foi possível encontrar o usuário selecionado.", nil)
+			}
+			member := data.Resolved.Members[data.TargetID]
+			if member != nil && member.User == nil {
+				member.User = user
+			}
+			return cmd.RunUser(ctx, inv, user, member)
+
+		case discordgo.MessageApplicationCommand:
+			if cmd.RunMessage == nil {
+				return fmt.Errorf("message command %s has no handler", cmd.Name)
+			}
+			message := data.Resolved.Messages[data.TargetID]
+			if message == nil {
+				return types.NewUserError("Não foi possível encontrar a mensagem selecionada.", nil)
+			}
+			if message.GuildID == "" {
+				message.GuildID = inv.GuildID
+			}
+			return cmd.RunMessage(ctx, inv, message)
+		}
+
+		return fmt.Errorf("command %s is not a context menu command", cmd.Name)
+	}
+}
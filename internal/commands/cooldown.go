@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"sync"
+	"time"
+)
+
+type cooldownTracker struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	lastPrune time.Time
+}
+
+func newCooldownTracker() *cooldownTracker {
+	return &cooldownTracker{
+		expires:   make(map[string]time.Time),
+		lastPrune: time.Now(),
+	}
+}
+
+// take registers a use of key and returns the remaining wait when key is
+// still on cooldown, in which case the use is not registered.
+func (c *cooldownTracker) take(key string, cooldown time.Duration) (time.Duration, bool) {
+	if cooldown <= 0 {
+		return 0, true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.lastPrune) > time.Minute {
+		for k, exp := range c.expires {
+			if now.After(exp) {
+				delete(c.expires, k)
+			}
+		}
+		c.lastPrune = now
+	}
+
+	if exp, ok := c.expires[key]; ok && now.Before(exp) {
+		return exp.Sub(now), false
+	}
+
+	c.expires[key] = now.Add(cooldown)
+	return 0, true
+}
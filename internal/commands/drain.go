@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const restartingMessage = "🔄 O bot está reiniciando. Tente novamente em instantes."
+
+// inflight counts the invocations that are running, so shutdown can wait
+// for them. Once draining, no new invocation is admitted.
+type inflight struct {
+	mu       sync.Mutex
+	draining bool
+	running  int
+	idle     chan struct{}
+}
+
+// begin admits an invocation, which must call end once it finishes.
+func (f *inflight) begin() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.draining {
+		return false
+	}
+	f.running++
+	return true
+}
+
+func (f *inflight) end() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.running--
+	if f.running == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// drain stops admitting invocations and returns a channel closed once the
+// running ones finished, with how many are still running.
+func (f *inflight) drain() (<-chan struct{}, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.draining = true
+	idle := make(chan struct{})
+	if f.running == 0 {
+		close(idle)
+	} else {
+		f.idle = idle
+	}
+	return idle, f.running
+}
+
+// Drain stops accepting commands and components, answering them with a
+// restarting notice instead, and waits until the running ones finish or
+// ctx is done.
+func (h *Handler) Drain(ctx context.Context) error {
+	idle, running := h.inflight.drain()
+	if running > 0 {
+		h.logger.Info("Waiting for running commands to finish", "running", running)
+	}
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		h.inflight.mu.Lock()
+		running := h.inflight.running
+		h.inflight.mu.Unlock()
+		return fmt.Errorf("%d commands still running: %v", running, ctx.Err())
+	}
+}
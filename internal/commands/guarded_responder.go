@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+var errInvocationExpired = errors.New("invocation expired")
+
+// guardedResponder stops a command from replying after it timed out, so a
+// late result never lands on top of the "Comando expirou" message.
+type guardedResponder struct {
+	next types.Responder
+
+	mu      sync.Mutex
+	expired bool
+	replied bool
+}
+
+func newGuardedResponder(next types.Responder) *guardedResponder {
+	return &guardedResponder{next: next}
+}
+
+// expire rejects every later reply. It reports whether the command had not
+// delivered a reply yet, in which case the caller should notify the user.
+func (r *guardedResponder) expire() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expired = true
+	return !r.replied
+}
+
+func (r *guardedResponder) send(reply bool, fn func() error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.expired {
+		return errInvocationExpired
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	if reply {
+		r.replied = true
+	}
+	return nil
+}
+
+func (r *guardedResponder) Defer(ephemeral bool) error {
+	return r.send(false, func() error { return r.next.Defer(ephemeral) })
+}
+
+func (r *guardedResponder) Respond(data *discordgo.InteractionResponseData) error {
+	return r.send(true, func() error { return r.next.Respond(data) })
+}
+
+func (r *guardedResponder) Edit(edit *discordgo.WebhookEdit) error {
+	return r.send(true, func() error { return r.next.Edit(edit) })
+}
+
+func (r *guardedResponder) FollowUp(params *discordgo.WebhookParams) error {
+	return r.send(true, func() error { return r.next.FollowUp(params) })
+}
@@ -4,31 +4,75 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	_ "github.com/kevinfinalboss/Void/commands/all"
 	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/cache"
+	"github.com/kevinfinalboss/Void/internal/database"
 	"github.com/kevinfinalboss/Void/internal/logger"
 	"github.com/kevinfinalboss/Void/internal/registry"
 	"github.com/kevinfinalboss/Void/internal/types"
 )
 
+// defaultCommandTimeout bounds commands that do not declare a Timeout.
+const defaultCommandTimeout = 15 * time.Second
+
 type Handler struct {
-	commands     map[string]*types.Command
-	session      *discordgo.Session
-	config       *config.Config
-	logger       *logger.Logger
-	commandMutex sync.RWMutex
+	commands        map[string]*types.Command
+	session         *discordgo.Session
+	config          atomic.Pointer[config.Config]
+	logger          *logger.Logger
+	db              *database.MongoDB
+	messages        *cache.MessageCache
+	cluster         types.Cluster
+	cooldowns       *cooldownTracker
+	metrics         *metrics
+	inflight        inflight
+	disabled        atomic.Int64
+	registers       atomic.Bool
+	middlewares     []types.Middleware
+	commandMutex    sync.RWMutex
+	middlewareMutex sync.RWMutex
+
+	// syncMutex serializes command syncs, and syncedScopes holds the scopes
+	// the last one registered commands to.
+	syncMutex    sync.Mutex
+	syncedScopes []string
 }
 
-func NewHandler(s *discordgo.Session, cfg *config.Config, l *logger.Logger) *Handler {
-	return &Handler{
-		commands: make(map[string]*types.Command),
-		session:  s,
-		config:   cfg,
-		logger:   l,
+func NewHandler(s *discordgo.Session, cfg *config.Config, l *logger.Logger, db *database.MongoDB, messages *cache.MessageCache) *Handler {
+	h := &Handler{
+		commands:  make(map[string]*types.Command),
+		session:   s,
+		logger:    l.Named("commands"),
+		db:        db,
+		messages:  messages,
+		cooldowns: newCooldownTracker(),
+		metrics:   newMetrics(),
 	}
+	h.config.Store(cfg)
+	h.registers.Store(true)
+	h.middlewares = h.defaultMiddlewares()
+	return h
+}
+
+// SetRegister sets whether LoadCommands registers the commands with
+// Discord. Commands are global to the application, so with several
+// processes only one of them registers.
+func (h *Handler) SetRegister(register bool) {
+	h.registers.Store(register)
+}
+
+// SetConfig makes cfg the configuration of new invocations.
+func (h *Handler) SetConfig(cfg *config.Config) {
+	h.config.Store(cfg)
+}
+
+func (h *Handler) currentConfig() *config.Config {
+	return h.config.Load()
 }
 
 func (h *Handler) LoadCommands() error {
@@ -39,37 +83,42 @@ func (h *Handler) LoadCommands() error {
 	defer cancel()
 
 	commands := make([]*discordgo.ApplicationCommand, 0, len(registry.Commands))
-	for name, cmd := range registry.Commands {
-		command := &discordgo.ApplicationCommand{
-			Name:        cmd.Name,
-			Description: cmd.Description,
-			Options:     make([]*discordgo.ApplicationCommandOption, 0, len(cmd.Options)),
+	loaded := make(map[string]*types.Command)
+	for _, registered := range registry.Commands {
+		cmd, ok := enabledCommand(h.currentConfig(), registered)
+		if !ok {
+			h.logger.Warn("Command disabled by missing configuration", "command", registered.Name)
+			continue
 		}
-
-		for _, opt := range cmd.Options {
-			command.Options = append(command.Options, &discordgo.ApplicationCommandOption{
-				Name:        opt.Name,
-				Description: opt.Description,
-				Type:        opt.Type,
-				Required:    opt.Required,
-				Choices:     opt.Choices,
-			})
+		cmd, err := applyArgs(cmd)
+		if err != nil {
+			return err
 		}
 
+		command, err := buildApplicationCommand(cmd)
+		if err != nil {
+			return err
+		}
 		commands = append(commands, command)
-		h.commandMutex.Lock()
-		h.commands[name] = cmd
-		h.commandMutex.Unlock()
+
+		for path, leaf := range flattenCommand(cmd) {
+			loaded[path] = leaf
+		}
+	}
+
+	// Replacing the table drops the commands a reload disabled.
+	h.commandMutex.Lock()
+	h.commands = loaded
+	h.commandMutex.Unlock()
+
+	if !h.registers.Load() {
+		h.logger.Info("Loaded commands, registration is left to the process serving shard 0", "count", len(commands))
+		return nil
 	}
 
 	done := make(chan error, 1)
 	go func() {
-		_, err := h.session.ApplicationCommandBulkOverwrite(
-			h.config.Discord.ClientID,
-			h.config.Discord.GuildID,
-			commands,
-		)
-		done <- err
+		done <- h.syncCommands(commands)
 		close(done)
 	}()
 
@@ -80,63 +129,112 @@ func (h *Handler) LoadCommands() error {
 		if err != nil {
 			return fmt.Errorf("failed to register commands: %v", err)
 		}
-		h.logger.Info(fmt.Sprintf("Successfully registered %d commands in %v", len(commands), time.Since(startTime)))
+		h.logger.Info("Successfully synchronised commands", "count", len(commands), "duration", time.Since(startTime))
 		return nil
 	}
 }
 
 func (h *Handler) HandleCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		h.handleApplicationCommand(s, i)
+	case discordgo.InteractionApplicationCommandAutocomplete:
 		h.handleAutocomplete(s, i)
-		return
-	}
-
-	if i.Type != discordgo.InteractionApplicationCommand {
-		return
+	case discordgo.InteractionMessageComponent, discordgo.InteractionModalSubmit:
+		h.handleComponent(s, i)
 	}
+}
 
+func (h *Handler) handleApplicationCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	h.commandMutex.RLock()
-	cmd, exists := h.commands[i.ApplicationCommandData().Name]
+	cmd, exists := h.commands[interactionKey(i)]
 	h.commandMutex.RUnlock()
 
 	if !exists {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	h.execute(s, i, cmd, nil)
+}
+
+// execute runs cmd through the middleware chain with a context that expires
+// after the command's Timeout. r, when nil, replies through the interaction
+// webhook.
+func (h *Handler) execute(s *discordgo.Session, i *discordgo.InteractionCreate, cmd *types.Command, r types.Responder) {
+	timeout := cmd.Timeout
+	if timeout <= 0 {
+		timeout = defaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	done := make(chan error, 1)
+	if r == nil {
+		r = types.NewInteractionResponder(s, i.Interaction)
+	}
+	if !h.available(cmd.Intents) {
+		h.replyError(r, false, unavailableMessage)
+		return
+	}
+	if !h.inflight.begin() {
+		h.replyError(r, false, restartingMessage)
+		return
+	}
+
+	guard := newGuardedResponder(r)
+	inv := h.newInvocation(s, i, guard)
+
+	run := h.chain(cmd)
+	done := make(chan struct{})
 	go func() {
-		done <- cmd.Run(s, i, h.config)
-		close(done)
+		defer h.inflight.end()
+		defer close(done)
+		run(ctx, inv)
 	}()
 
 	select {
+	case <-done:
 	case <-ctx.Done():
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "Comando expirou. Tente novamente.",
-				Flags:   discordgo.MessageFlagsEphemeral,
-			},
-		})
-	case err := <-done:
-		if err != nil {
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "Ocorreu um erro ao executar o comando.",
-					Flags:   discordgo.MessageFlagsEphemeral,
-				},
-			})
+		if guard.expire() {
+			h.replyError(r, cmd.Defer, "Comando expirou. Tente novamente.")
 		}
 	}
 }
 
+// newInvocation builds the invocation of i with the handler's dependencies.
+func (h *Handler) newInvocation(s *discordgo.Session, i *discordgo.InteractionCreate, r types.Responder) *types.Invocation {
+	inv := types.NewInvocation(s, i, h.currentConfig(), h.logger, h.db, r)
+	inv.Logger = h.logger.With("interaction", interactionName(i), "guild", inv.GuildID,
+		"user", inv.UserID(), "shard", s.ShardID)
+	inv.Messages = h.messages
+	inv.Cluster = h.cluster
+	return inv
+}
+
+// interactionName names an interaction in logs: the command name, or the
+// CustomID of components and modals.
+func interactionName(i *discordgo.InteractionCreate) string {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand, discordgo.InteractionApplicationCommandAutocomplete:
+		return i.ApplicationCommandData().Name
+	default:
+		return types.CustomID(i)
+	}
+}
+
+// SetCluster sets the cluster commands query for bot-wide statistics.
+func (h *Handler) SetCluster(c types.Cluster) {
+	h.cluster = c
+}
+
+// Stats returns a snapshot of the execution counters collected for each
+// command since the handler was created.
+func (h *Handler) Stats() map[string]CommandStats {
+	return h.metrics.snapshot()
+}
+
 func (h *Handler) handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	h.commandMutex.RLock()
-	cmd, exists := h.commands[i.ApplicationCommandData().Name]
+	cmd, exists := h.commands[interactionKey(i)]
 	h.commandMutex.RUnlock()
 
 	if !exists || cmd.AutoComplete == nil {
@@ -146,10 +244,11 @@ func (h *Handler) handleAutocomplete(s *discordgo.Session, i *discordgo.Interact
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	inv := h.newInvocation(s, i, nil)
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
-		choices, err := cmd.AutoComplete(s, i)
+		choices, err := cmd.AutoComplete(ctx, inv)
 		if err != nil {
 			return
 		}
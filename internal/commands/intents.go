@@ -0,0 +1,40 @@
+package commands
+
+import "github.com/bwmarrin/discordgo"
+
+// prefixIntents deliver the messages prefix commands are invoked with, in
+// guilds and in DMs.
+const prefixIntents = discordgo.IntentGuildMessages | discordgo.IntentDirectMessages | discordgo.IntentMessageContent
+
+// unavailableMessage answers commands that need a disabled intent.
+const unavailableMessage = "❌ Este comando está indisponível no momento."
+
+// DisableIntents marks intents the sessions do not identify with, such as
+// privileged intents not enabled for the application. Commands that need
+// one are refused and prefix commands are ignored if they need one.
+func (h *Handler) DisableIntents(intents discordgo.Intent) {
+	h.disabled.Store(int64(intents))
+}
+
+func (h *Handler) available(need discordgo.Intent) bool {
+	return need&discordgo.Intent(h.disabled.Load()) == 0
+}
+
+// Intents returns the gateway intents needed by the loaded commands, keyed
+// by command name.
+func (h *Handler) Intents() map[string]discordgo.Intent {
+	h.commandMutex.RLock()
+	defer h.commandMutex.RUnlock()
+
+	intents := make(map[string]discordgo.Intent)
+	for _, cmd := range h.commands {
+		intent := cmd.Intents
+		if cmd.AllowPrefix {
+			intent |= prefixIntents
+		}
+		if intent != 0 {
+			intents["command "+cmd.Name] = intent
+		}
+	}
+	return intents
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"sync"
+	"time"
+)
+
+type CommandStats struct {
+	Invocations   uint64
+	Failures      uint64
+	TotalDuration time.Duration
+}
+
+type metrics struct {
+	mu    sync.Mutex
+	stats map[string]*CommandStats
+}
+
+func newMetrics() *metrics {
+	return &metrics{stats: make(map[string]*CommandStats)}
+}
+
+func (m *metrics) record(name string, d time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.stats[name]
+	if !ok {
+		st = &CommandStats{}
+		m.stats[name] = st
+	}
+	st.Invocations++
+	st.TotalDuration += d
+	if failed {
+		st.Failures++
+	}
+}
+
+func (m *metrics) snapshot() map[string]CommandStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]CommandStats, len(m.stats))
+	for name, st := range m.stats {
+		out[name] = *st
+	}
+	return out
+}
@@ -0,0 +1,230 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+// chain composes the default middleware, the middleware registered with
+// Use and the command's own middleware around cmd.Run, outermost first.
+func (h *Handler) chain(cmd *types.Command) types.RunFunc {
+	h.middlewareMutex.RLock()
+	mws := make([]types.Middleware, 0, len(h.middlewares)+len(cmd.Middlewares))
+	mws = append(mws, h.middlewares...)
+	h.middlewareMutex.RUnlock()
+	mws = append(mws, cmd.Middlewares...)
+
+	run := cmd.Run
+	for idx := len(mws) - 1; idx >= 0; idx-- {
+		run = mws[idx](cmd, run)
+	}
+	return run
+}
+
+// Use appends middleware that runs for every command, after the default
+// middleware and before any middleware declared on the command itself.
+func (h *Handler) Use(mws ...types.Middleware) {
+	h.middlewareMutex.Lock()
+	defer h.middlewareMutex.Unlock()
+	h.middlewares = append(h.middlewares, mws...)
+}
+
+func (h *Handler) defaultMiddlewares() []types.Middleware {
+	return []types.Middleware{
+		h.loggingMiddleware,
+		h.metricsMiddleware,
+		h.errorMiddleware,
+		recoveryMiddleware,
+		h.permissionMiddleware,
+		argsMiddleware,
+		h.cooldownMiddleware,
+		deferMiddleware,
+	}
+}
+
+func (h *Handler) loggingMiddleware(cmd *types.Command, next types.RunFunc) types.RunFunc {
+	return func(ctx context.Context, inv *types.Invocation) error {
+		start := time.Now()
+		err := next(ctx, inv)
+		if err != nil {
+			inv.Logger.Error("Command failed", "duration", time.Since(start), "error", err)
+			return err
+		}
+		if inv.Config.Debug {
+			inv.Logger.Debug("Command executed", "duration", time.Since(start))
+		}
+		return nil
+	}
+}
+
+func (h *Handler) metricsMiddleware(cmd *types.Command, next types.RunFunc) types.RunFunc {
+	return func(ctx context.Context, inv *types.Invocation) error {
+		start := time.Now()
+		err := next(ctx, inv)
+		h.metrics.record(cmd.Name, time.Since(start), err != nil)
+		return err
+	}
+}
+
+// errorMiddleware reports failures to the user. The error is still returned
+// so outer middleware can log and count it. Failures caused by the timeout
+// are reported by execute instead.
+func (h *Handler) errorMiddleware(cmd *types.Command, next types.RunFunc) types.RunFunc {
+	return func(ctx context.Context, inv *types.Invocation) error {
+		err := next(ctx, inv)
+		if err == nil || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return err
+		}
+
+		message := "Ocorreu um erro ao executar o comando."
+		var userErr *types.UserError
+		if errors.As(err, &userErr) {
+			message = userErr.Message
+		}
+
+		h.replyError(inv.Responder, cmd.Defer, message)
+		return err
+	}
+}
+
+func recoveryMiddleware(cmd *types.Command, next types.RunFunc) types.RunFunc {
+	return func(ctx context.Context, inv *types.Invocation) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic in command %s: %v\n%s", cmd.Name, r, debug.Stack())
+			}
+		}()
+		return next(ctx, inv)
+	}
+}
+
+func (h *Handler) permissionMiddleware(cmd *types.Command, next types.RunFunc) types.RunFunc {
+	return func(ctx context.Context, inv *types.Invocation) error {
+		if cmd.DevOnly && !types.IsDeveloper(inv.Config, inv.UserID()) {
+			h.respondEphemeral(inv.Responder, "❌ Este comando é restrito aos desenvolvedores do bot.")
+			return nil
+		}
+
+		if cmd.AdminOnly && !inv.IsAdmin() {
+			h.respondEphemeral(inv.Responder, "❌ Você precisa da permissão de Administrador ou Gerenciar Servidor, ou de um cargo de gerência, para usar este comando.")
+			return nil
+		}
+
+		return next(ctx, inv)
+	}
+}
+
+func (h *Handler) cooldownMiddleware(cmd *types.Command, next types.RunFunc) types.RunFunc {
+	return func(ctx context.Context, inv *types.Invocation) error {
+		key := fmt.Sprintf("%s:%s:%s", inv.GuildID, inv.UserID(), cmd.Name)
+		if remaining, ok := h.cooldowns.take(key, cmd.Cooldown); !ok {
+			h.respondEphemeral(inv.Responder, fmt.Sprintf("⏳ Aguarde %s para usar este comando novamente.", formatRemaining(remaining)))
+			return nil
+		}
+
+		return next(ctx, inv)
+	}
+}
+
+// argsMiddleware binds the arguments of commands declaring Args into
+// inv.Args, rejecting invalid ones before they consume a cooldown or
+// acknowledge the interaction.
+func argsMiddleware(cmd *types.Command, next types.RunFunc) types.RunFunc {
+	if cmd.Args == nil {
+		return next
+	}
+
+	argsType := reflect.TypeOf(cmd.Args)
+	return func(ctx context.Context, inv *types.Invocation) error {
+		args := reflect.New(argsType).Interface()
+		if err := inv.Bind(args); err != nil {
+			return err
+		}
+		inv.Args = args
+		return next(ctx, inv)
+	}
+}
+
+func deferMiddleware(cmd *types.Command, next types.RunFunc) types.RunFunc {
+	if !cmd.Defer {
+		return next
+	}
+
+	return func(ctx context.Context, inv *types.Invocation) error {
+		if err := inv.Responder.Defer(false); err != nil {
+			return fmt.Errorf("falha ao enviar a resposta inicial: %v", err)
+		}
+
+		return next(ctx, inv)
+	}
+}
+
+// replyError shows message to the user. deferred tells whether the
+// interaction was acknowledged with a deferred response.
+func (h *Handler) replyError(r types.Responder, deferred bool, message string) {
+	embed := &discordgo.MessageEmbed{
+		Title:       "❌ Erro",
+		Description: message,
+		Color:       0xff0000,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Devil • Erro",
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if deferred {
+		err := r.Edit(&discordgo.WebhookEdit{
+			Embeds: &[]*discordgo.MessageEmbed{embed},
+		})
+		if err == nil {
+			return
+		}
+		// Failures before the defer middleware ran leave the interaction
+		// unacknowledged, so fall back to a regular response.
+	}
+
+	err := r.Respond(&discordgo.InteractionResponseData{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Flags:  discordgo.MessageFlagsEphemeral,
+	})
+	if err == nil {
+		return
+	}
+
+	// The command already acknowledged the interaction on its own.
+	err = r.FollowUp(&discordgo.WebhookParams{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Flags:  discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		h.logger.Error("Failed to send error followup", "error", err)
+	}
+}
+
+func (h *Handler) respondEphemeral(r types.Responder, content string) {
+	err := r.Respond(&discordgo.InteractionResponseData{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+	if err != nil {
+		h.logger.Error("Failed to respond to interaction", "error", err)
+	}
+}
+
+func formatRemaining(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	if seconds < 60 {
+		return fmt.Sprintf("%ds", seconds)
+	}
+	return fmt.Sprintf("%dm%02ds", seconds/60, seconds%60)
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+// recordingResponder records the replies of an invocation.
+type recordingResponder struct {
+	replies []*discordgo.InteractionResponseData
+}
+
+func (r *recordingResponder) Defer(bool) error { return nil }
+
+func (r *recordingResponder) Respond(data *discordgo.InteractionResponseData) error {
+	r.replies = append(r.replies, data)
+	return nil
+}
+
+func (r *recordingResponder) Edit(*discordgo.WebhookEdit) error       { return nil }
+func (r *recordingResponder) FollowUp(*discordgo.WebhookParams) error { return nil }
+
+func testInvocation(cfg *config.Config, userID, guildID string, permissions int64) *types.Invocation {
+	i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{GuildID: guildID}}
+	inv := &types.Invocation{
+		Interaction: i,
+		Config:      cfg,
+		Responder:   &recordingResponder{},
+		User:        &discordgo.User{ID: userID},
+		GuildID:     guildID,
+	}
+	if guildID != "" {
+		inv.Member = &discordgo.Member{User: inv.User, Permissions: permissions}
+		i.Member = inv.Member
+	}
+	return inv
+}
+
+// runMiddleware runs mw around a command that counts its runs, and reports
+// whether it ran and the ephemeral reply sent instead.
+func runMiddleware(t *testing.T, mw types.Middleware, cmd *types.Command, inv *types.Invocation) (bool, string) {
+	ran := false
+	next := func(context.Context, *types.Invocation) error {
+		ran = true
+		return nil
+	}
+	if err := mw(cmd, next)(context.Background(), inv); err != nil {
+		t.Fatal(err)
+	}
+
+	r := inv.Responder.(*recordingResponder)
+	if len(r.replies) == 0 {
+		return ran, ""
+	}
+	reply := r.replies[len(r.replies)-1]
+	if reply.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		return ran, "not ephemeral: " + reply.Content
+	}
+	return ran, reply.Content
+}
+
+func TestPermissionMiddleware(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Discord.Devs = []string{"dev"}
+
+	tests := []struct {
+		name        string
+		cmd         *types.Command
+		userID      string
+		guildID     string
+		permissions int64
+		allowed     bool
+	}{
+		{"unrestricted", &types.Command{Name: "ping"}, "user", "guild", 0, true},
+		{"developer", &types.Command{Name: "eval", DevOnly: true}, "dev", "guild", 0, true},
+		{"not a developer", &types.Command{Name: "eval", DevOnly: true}, "user", "guild", discordgo.PermissionAdministrator, false},
+		{"administrator", &types.Command{Name: "config", AdminOnly: true}, "user", "guild", discordgo.PermissionAdministrator, true},
+		{"manage server", &types.Command{Name: "config", AdminOnly: true}, "user", "guild", discordgo.PermissionManageServer, true},
+		{"member", &types.Command{Name: "config", AdminOnly: true}, "user", "guild", discordgo.PermissionSendMessages, false},
+		{"outside a guild", &types.Command{Name: "config", AdminOnly: true}, "dev", "", 0, false},
+	}
+
+	h := &Handler{}
+	for _, tt := range tests {
+		inv := testInvocation(cfg, tt.userID, tt.guildID, tt.permissions)
+		ran, reply := runMiddleware(t, h.permissionMiddleware, tt.cmd, inv)
+		if ran != tt.allowed {
+			t.Errorf("%s: ran = %v, want %v", tt.name, ran, tt.allowed)
+		}
+		if !tt.allowed && reply == "" {
+			t.Errorf("%s: denied without an ephemeral reply", tt.name)
+		}
+	}
+}
+
+func TestCooldownMiddleware(t *testing.T) {
+	cfg := &config.Config{}
+	h := &Handler{cooldowns: newCooldownTracker()}
+	cmd := &types.Command{Name: "daily", Cooldown: time.Hour}
+
+	tests := []struct {
+		name    string
+		cmd     *types.Command
+		userID  string
+		guildID string
+		allowed bool
+	}{
+		{"first use", cmd, "a", "g1", true},
+		{"second use", cmd, "a", "g1", false},
+		{"another user", cmd, "b", "g1", true},
+		{"another guild", cmd, "a", "g2", true},
+		{"another command", &types.Command{Name: "weekly", Cooldown: time.Hour}, "a", "g1", true},
+		{"no cooldown", &types.Command{Name: "ping"}, "a", "g1", true},
+		{"no cooldown again", &types.Command{Name: "ping"}, "a", "g1", true},
+	}
+	for _, tt := range tests {
+		ran, reply := runMiddleware(t, h.cooldownMiddleware, tt.cmd, testInvocation(cfg, tt.userID, tt.guildID, 0))
+		if ran != tt.allowed {
+			t.Errorf("%s: ran = %v, want %v", tt.name, ran, tt.allowed)
+		}
+		if !tt.allowed && reply == "" {
+			t.Errorf("%s: rejected without an ephemeral reply", tt.name)
+		}
+	}
+}
+
+func TestCooldownTrackerExpires(t *testing.T) {
+	c := newCooldownTracker()
+	if _, ok := c.take("k", 20*time.Millisecond); !ok {
+		t.Fatal("first take() rejected")
+	}
+	if remaining, ok := c.take("k", 20*time.Millisecond); ok || remaining <= 0 || remaining > 20*time.Millisecond {
+		t.Errorf("take() during cooldown = %v, %v", remaining, ok)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.take("k", 20*time.Millisecond); !ok {
+		t.Error("take() rejected after the cooldown expired")
+	}
+}
+
+func TestFormatRemaining(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                       "1s",
+		1400 * time.Millisecond: "1s",
+		59 * time.Second:        "59s",
+		90 * time.Second:        "1m30s",
+		time.Hour + time.Second: "60m01s",
+	}
+	for d, want := range tests {
+		if got := formatRemaining(d); got != want {
+			t.Errorf("formatRemaining(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleMessage dispatches prefix invocations of commands that set
+// AllowPrefix. The message is turned into a synthetic interaction so the
+// command runs through the same middleware and Run logic as a slash command.
+func (h *Handler) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author == nil || m.Author.Bot || m.Content == "" || !h.available(prefixIntents) {
+		return
+	}
+
+	prefix := h.guildPrefix(m.GuildID)
+	content, ok := stripPrefix(s, m.Content, prefix)
+	if !ok {
+		return
+	}
+
+	tokens, err := tokenize(content)
+	if err != nil || len(tokens) == 0 {
+		return
+	}
+
+	path := []string{strings.ToLower(tokens[0])}
+	tokens = tokens[1:]
+
+	h.commandMutex.RLock()
+	cmd, exists := h.commands[strings.Join(path, " ")]
+	for !exists && len(tokens) > 0 && len(path) < 3 {
+		path = append(path, strings.ToLower(tokens[0]))
+		tokens = tokens[1:]
+		cmd, exists = h.commands[strings.Join(path, " ")]
+	}
+	h.commandMutex.RUnlock()
+
+	// Slash-only commands are ignored silently: the message is more likely
+	// chat that happens to start with the prefix than an invocation.
+	if !exists || isContextMenu(cmd) || !cmd.AllowPrefix {
+		return
+	}
+
+	options, attachments, err := bindPrefixOptions(cmd, tokens, m.Attachments)
+	if err != nil {
+		s.ChannelMessageSendReply(m.ChannelID,
+			fmt.Sprintf("❌ %s.\nUso: `%s`", err.Error(), prefixUsage(prefix, cmd)),
+			m.Reference())
+		return
+	}
+
+	i := h.prefixInteraction(s, m, path, options, attachments)
+	h.execute(s, i, cmd, newPrefixResponder(s, m.Message))
+}
+
+func (h *Handler) guildPrefix(guildID string) string {
+	if guildID == "" || h.db == nil {
+		return h.currentConfig().Discord.Prefix
+	}
+
+	settings, err := h.db.GetGuildSettings(guildID)
+	if err != nil {
+		h.logger.Error("Failed to load guild settings", "guild", guildID, "error", err)
+		return h.currentConfig().Discord.Prefix
+	}
+	if settings.Prefix == "" {
+		return h.currentConfig().Discord.Prefix
+	}
+	return settings.Prefix
+}
+
+// stripPrefix accepts both the configured prefix and a mention of the bot.
+func stripPrefix(s *discordgo.Session, content, prefix string) (string, bool) {
+	if rest, ok := strings.CutPrefix(content, prefix); ok {
+		return rest, true
+	}
+
+	if s.State != nil && s.State.User != nil {
+		for _, mention := range []string{"<@" + s.State.User.ID + ">", "<@!" + s.State.User.ID + ">"} {
+			if rest, ok := strings.CutPrefix(content, mention); ok {
+				return strings.TrimSpace(rest), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func (h *Handler) prefixInteraction(s *discordgo.Session, m *discordgo.MessageCreate, path []string, options []*discordgo.ApplicationCommandInteractionDataOption, attachments map[string]*discordgo.MessageAttachment) *discordgo.InteractionCreate {
+	// Wrap the leaf options in the subcommand and group options Discord
+	// would send, innermost first.
+	for idx := len(path) - 1; idx > 0; idx-- {
+		optType := discordgo.ApplicationCommandOptionSubCommand
+		if idx < len(path)-1 {
+			optType = discordgo.ApplicationCommandOptionSubCommandGroup
+		}
+		options = []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name:    path[idx],
+			Type:    optType,
+			Options: options,
+		}}
+	}
+
+	interaction := &discordgo.Interaction{
+		ID:        "prefix-" + m.ID,
+		Type:      discordgo.InteractionApplicationCommand,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name:        path[0],
+			CommandType: discordgo.ChatApplicationCommand,
+			Options:     options,
+			Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+				Attachments: attachments,
+			},
+		},
+	}
+
+	if m.GuildID == "" {
+		interaction.User = m.Author
+		return &discordgo.InteractionCreate{Interaction: interaction}
+	}
+
+	member := &discordgo.Member{GuildID: m.GuildID, User: m.Author}
+	if m.Member != nil {
+		copied := *m.Member
+		copied.GuildID = m.GuildID
+		copied.User = m.Author
+		member = &copied
+	}
+	perms, err := messagePermissions(s, m)
+	if err != nil {
+		h.logger.Error("Failed to resolve member permissions", "guild", m.GuildID, "user", m.Author.ID, "error", err)
+	}
+	member.Permissions = perms
+	interaction.Member = member
+
+	return &discordgo.InteractionCreate{Interaction: interaction}
+}
+
+// messagePermissions returns the permissions of the author of m in its
+// channel. The member sent with the message covers authors missing from the
+// state cache, which is most of them without the guild members intent; the
+// REST API is the last resort.
+func messagePermissions(s *discordgo.Session, m *discordgo.MessageCreate) (int64, error) {
+	if m.Member != nil {
+		if perms, err := s.State.MessagePermissions(m.Message); err == nil {
+			return perms, nil
+		}
+	}
+	return s.UserChannelPermissions(m.Author.ID, m.ChannelID)
+}
@@ -0,0 +1,245 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+var (
+	userMentionRe    = regexp.MustCompile(`^<@!?(\d+)>$`)
+	roleMentionRe    = regexp.MustCompile(`^<@&(\d+)>$`)
+	channelMentionRe = regexp.MustCompile(`^<#(\d+)>$`)
+	snowflakeRe      = regexp.MustCompile(`^\d{15,21}$`)
+)
+
+// tokenize splits a prefix command line on whitespace, keeping quoted
+// sections together. Both single and double quotes are accepted and a
+// backslash escapes the next character.
+func tokenize(input string) ([]string, error) {
+	var (
+		tokens  []string
+		current strings.Builder
+		quote   rune
+		escaped bool
+		inToken bool
+	)
+
+	for _, r := range input {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("aspas não fechadas")
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// bindPrefixOptions maps the tokens of a prefix invocation onto the options
+// declared by cmd. Arguments may be given positionally or as name:value, and
+// attachment options are filled with the message attachments in order.
+func bindPrefixOptions(cmd *types.Command, tokens []string, attachments []*discordgo.MessageAttachment) ([]*discordgo.ApplicationCommandInteractionDataOption, map[string]*discordgo.MessageAttachment, error) {
+	named := make(map[string]string)
+	var positional []string
+	for _, tok := range tokens {
+		if name, value, ok := strings.Cut(tok, ":"); ok && value != "" && findOption(cmd.Options, name) != nil {
+			named[strings.ToLower(name)] = value
+			continue
+		}
+		positional = append(positional, tok)
+	}
+
+	remaining := 0
+	for _, opt := range cmd.Options {
+		if _, ok := named[opt.Name]; !ok && opt.Type != discordgo.ApplicationCommandOptionAttachment {
+			remaining++
+		}
+	}
+
+	options := make([]*discordgo.ApplicationCommandInteractionDataOption, 0, len(cmd.Options))
+	resolved := make(map[string]*discordgo.MessageAttachment)
+
+	for _, opt := range cmd.Options {
+		if opt.Type == discordgo.ApplicationCommandOptionAttachment {
+			if len(attachments) == 0 {
+				if opt.Required {
+					return nil, nil, fmt.Errorf("anexe um arquivo para `%s`", opt.Name)
+				}
+				continue
+			}
+			a := attachments[0]
+			attachments = attachments[1:]
+			resolved[a.ID] = a
+			options = append(options, &discordgo.ApplicationCommandInteractionDataOption{
+				Name:  opt.Name,
+				Type:  opt.Type,
+				Value: a.ID,
+			})
+			continue
+		}
+
+		raw, ok := named[opt.Name]
+		if !ok {
+			remaining--
+			switch {
+			case len(positional) == 0:
+				if opt.Required {
+					return nil, nil, fmt.Errorf("o argumento `%s` é obrigatório", opt.Name)
+				}
+				continue
+			case remaining == 0 && opt.Type == discordgo.ApplicationCommandOptionString:
+				// The last string option takes the rest of the line.
+				raw = strings.Join(positional, " ")
+				positional = nil
+			default:
+				raw = positional[0]
+				positional = positional[1:]
+			}
+		}
+
+		value, err := convertPrefixValue(opt, raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		options = append(options, &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  opt.Name,
+			Type:  opt.Type,
+			Value: value,
+		})
+	}
+
+	if len(positional) > 0 {
+		return nil, nil, fmt.Errorf("argumentos demais: %s", strings.Join(positional, " "))
+	}
+
+	return options, resolved, nil
+}
+
+// convertPrefixValue converts raw into the representation Discord uses for
+// option values in interaction payloads, so command handlers read prefix and
+// slash invocations the same way.
+func convertPrefixValue(opt *types.CommandOption, raw string) (interface{}, error) {
+	if len(opt.Choices) > 0 {
+		choice := matchChoice(opt.Choices, raw)
+		if choice == nil {
+			names := make([]string, 0, len(opt.Choices))
+			for _, c := range opt.Choices {
+				names = append(names, fmt.Sprint(c.Value))
+			}
+			return nil, fmt.Errorf("valor inválido para `%s`, use: %s", opt.Name, strings.Join(names, ", "))
+		}
+		raw = fmt.Sprint(choice.Value)
+	}
+
+	switch opt.Type {
+	case discordgo.ApplicationCommandOptionString:
+		return raw, nil
+	case discordgo.ApplicationCommandOptionInteger:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("`%s` deve ser um número inteiro", opt.Name)
+		}
+		return float64(n), nil
+	case discordgo.ApplicationCommandOptionNumber:
+		f, err := strconv.ParseFloat(strings.Replace(raw, ",", ".", 1), 64)
+		if err != nil {
+			return nil, fmt.Errorf("`%s` deve ser um número", opt.Name)
+		}
+		return f, nil
+	case discordgo.ApplicationCommandOptionBoolean:
+		switch strings.ToLower(raw) {
+		case "true", "sim", "s", "yes", "y", "1", "on":
+			return true, nil
+		case "false", "não", "nao", "n", "no", "0", "off":
+			return false, nil
+		}
+		return nil, fmt.Errorf("`%s` deve ser sim ou não", opt.Name)
+	case discordgo.ApplicationCommandOptionUser:
+		return parseMention(opt.Name, raw, userMentionRe, "um usuário")
+	case discordgo.ApplicationCommandOptionRole:
+		return parseMention(opt.Name, raw, roleMentionRe, "um cargo")
+	case discordgo.ApplicationCommandOptionChannel:
+		return parseMention(opt.Name, raw, channelMentionRe, "um canal")
+	case discordgo.ApplicationCommandOptionMentionable:
+		if id, err := parseMention(opt.Name, raw, userMentionRe, ""); err == nil {
+			return id, nil
+		}
+		return parseMention(opt.Name, raw, roleMentionRe, "um usuário ou cargo")
+	}
+
+	return nil, fmt.Errorf("o argumento `%s` não é suportado em comandos de prefixo", opt.Name)
+}
+
+func parseMention(name, raw string, re *regexp.Regexp, kind string) (string, error) {
+	if m := re.FindStringSubmatch(raw); m != nil {
+		return m[1], nil
+	}
+	if snowflakeRe.MatchString(raw) {
+		return raw, nil
+	}
+	return "", fmt.Errorf("`%s` deve mencionar %s", name, kind)
+}
+
+func matchChoice(choices []*discordgo.ApplicationCommandOptionChoice, raw string) *discordgo.ApplicationCommandOptionChoice {
+	for _, c := range choices {
+		if fmt.Sprint(c.Value) == raw || strings.EqualFold(c.Name, raw) {
+			return c
+		}
+	}
+	return nil
+}
+
+func findOption(opts []*types.CommandOption, name string) *types.CommandOption {
+	for _, opt := range opts {
+		if strings.EqualFold(opt.Name, name) {
+			return opt
+		}
+	}
+	return nil
+}
+
+func prefixUsage(prefix string, cmd *types.Command) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.WriteString(cmd.Name)
+	for _, opt := range cmd.Options {
+		if opt.Required {
+			fmt.Fprintf(&b, " <%s>", opt.Name)
+		} else {
+			fmt.Fprintf(&b, " [%s]", opt.Name)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"  a   b\tc\n", []string{"a", "b", "c"}},
+		{`"a b" c`, []string{"a b", "c"}},
+		{`'a "b"' c`, []string{`a "b"`, "c"}},
+		{`nome:"a b"`, []string{"nome:a b"}},
+		{`a\ b \"c`, []string{"a b", `"c`}},
+		{`""`, []string{""}},
+		{"<@123> <#456>", []string{"<@123>", "<#456>"}},
+	}
+	for _, tt := range tests {
+		got, err := tokenize(tt.input)
+		if err != nil {
+			t.Errorf("tokenize(%q) = %v", tt.input, err)
+			continue
+		}
+		if len(got) != len(tt.want) || strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{`"a b`, `a 'b`} {
+		if _, err := tokenize(input); err == nil {
+			t.Errorf("tokenize(%q) accepted unclosed quotes", input)
+		}
+	}
+}
+
+func prefixCommand(options ...*types.CommandOption) *types.Command {
+	return &types.Command{Name: "test", Options: options}
+}
+
+func TestBindPrefixOptions(t *testing.T) {
+	user := &types.CommandOption{Name: "usuario", Type: discordgo.ApplicationCommandOptionUser, Required: true}
+	count := &types.CommandOption{Name: "quantidade", Type: discordgo.ApplicationCommandOptionInteger}
+	reason := &types.CommandOption{Name: "motivo", Type: discordgo.ApplicationCommandOptionString}
+	silent := &types.CommandOption{Name: "silencioso", Type: discordgo.ApplicationCommandOptionBoolean}
+	channel := &types.CommandOption{Name: "canal", Type: discordgo.ApplicationCommandOptionChannel}
+	mode := &types.CommandOption{Name: "modo", Type: discordgo.ApplicationCommandOptionString, Choices: []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "Rápido", Value: "fast"},
+		{Name: "Lento", Value: "slow"},
+	}}
+	moderation := prefixCommand(user, count, reason)
+
+	tests := []struct {
+		name  string
+		cmd   *types.Command
+		input string
+		want  map[string]interface{}
+	}{
+		{"positional", moderation, "<@123> 5 spam", map[string]interface{}{
+			"usuario": "123", "quantidade": float64(5), "motivo": "spam",
+		}},
+		{"nickname mention", moderation, "<@!123>", map[string]interface{}{"usuario": "123"}},
+		{"raw snowflake", moderation, "123456789012345678", map[string]interface{}{"usuario": "123456789012345678"}},
+		{"last string takes the rest", moderation, "<@123> 5 flood no canal", map[string]interface{}{
+			"usuario": "123", "quantidade": float64(5), "motivo": "flood no canal",
+		}},
+		{"quoted string", moderation, `<@123> 5 "flood  duplo"`, map[string]interface{}{
+			"usuario": "123", "quantidade": float64(5), "motivo": "flood  duplo",
+		}},
+		{"named", moderation, "motivo:spam quantidade:2 <@123>", map[string]interface{}{
+			"usuario": "123", "quantidade": float64(2), "motivo": "spam",
+		}},
+		{"named is case insensitive", moderation, "<@123> QUANTIDADE:2", map[string]interface{}{
+			"usuario": "123", "quantidade": float64(2),
+		}},
+		{"colon in a positional value", prefixCommand(reason), "hora: 10:30", map[string]interface{}{
+			"motivo": "hora: 10:30",
+		}},
+		{"boolean", prefixCommand(silent), "sim", map[string]interface{}{"silencioso": true}},
+		{"channel mention", prefixCommand(channel), "<#456>", map[string]interface{}{"canal": "456"}},
+		{"choice by name", prefixCommand(mode), "lento", map[string]interface{}{"modo": "slow"}},
+		{"choice by value", prefixCommand(mode), "fast", map[string]interface{}{"modo": "fast"}},
+	}
+
+	for _, tt := range tests {
+		tokens, err := tokenize(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		options, _, err := bindPrefixOptions(tt.cmd, tokens, nil)
+		if err != nil {
+			t.Errorf("%s: bindPrefixOptions() = %v", tt.name, err)
+			continue
+		}
+
+		got := make(map[string]interface{}, len(options))
+		for _, opt := range options {
+			got[opt.Name] = opt.Value
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: bound %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for name, value := range tt.want {
+			if got[name] != value {
+				t.Errorf("%s: %s = %#v, want %#v", tt.name, name, got[name], value)
+			}
+		}
+	}
+}
+
+func TestBindPrefixOptionsErrors(t *testing.T) {
+	user := &types.CommandOption{Name: "usuario", Type: discordgo.ApplicationCommandOptionUser, Required: true}
+	count := &types.CommandOption{Name: "quantidade", Type: discordgo.ApplicationCommandOptionInteger}
+	file := &types.CommandOption{Name: "arquivo", Type: discordgo.ApplicationCommandOptionAttachment, Required: true}
+
+	tests := []struct {
+		name  string
+		cmd   *types.Command
+		input string
+	}{
+		{"missing required", prefixCommand(user, count), ""},
+		{"missing required given by name only", prefixCommand(user, count), "quantidade:2"},
+		{"not a mention", prefixCommand(user), "alguém"},
+		{"role mention for a user", prefixCommand(user), "<@&123>"},
+		{"not an integer", prefixCommand(user, count), "<@123> muitos"},
+		{"too many arguments", prefixCommand(user, count), "<@123> 2 3"},
+		{"missing attachment", prefixCommand(file), ""},
+	}
+	for _, tt := range tests {
+		tokens, err := tokenize(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := bindPrefixOptions(tt.cmd, tokens, nil); err == nil {
+			t.Errorf("%s: bindPrefixOptions(%q) = nil, want an error", tt.name, tt.input)
+		}
+	}
+}
+
+func TestBindPrefixOptionsAttachments(t *testing.T) {
+	cmd := prefixCommand(
+		&types.CommandOption{Name: "arquivo", Type: discordgo.ApplicationCommandOptionAttachment, Required: true},
+		&types.CommandOption{Name: "legenda", Type: discordgo.ApplicationCommandOptionString},
+	)
+	attachment := &discordgo.MessageAttachment{ID: "77", Filename: "a.png"}
+
+	options, resolved, err := bindPrefixOptions(cmd, []string{"uma", "foto"}, []*discordgo.MessageAttachment{attachment})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(options) != 2 || options[0].Value != "77" || options[1].Value != "uma foto" {
+		t.Errorf("bound %+v %+v", options[0], options[1])
+	}
+	if resolved["77"] != attachment {
+		t.Errorf("resolved = %v", resolved)
+	}
+}
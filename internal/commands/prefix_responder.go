@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// privateReplyLifetime is how long a private reply that could not be sent
+// by DM stays in the channel.
+const privateReplyLifetime = 15 * time.Second
+
+// prefixResponder delivers the replies of a prefix invocation as regular
+// channel messages. The first reply is sent as a reply to the invoking
+// message and later edits update it in place. Ephemeral replies, such as
+// permission denials and /help, go to the author by DM instead; when the
+// author does not accept DMs they are posted in the channel and deleted
+// shortly after.
+type prefixResponder struct {
+	session *discordgo.Session
+	message *discordgo.Message
+
+	mu sync.Mutex
+	// ephemeral is set by an ephemeral Defer, whose reply is sent by Edit.
+	ephemeral    bool
+	replyChannel string
+	replyID      string
+}
+
+func newPrefixResponder(s *discordgo.Session, m *discordgo.Message) *prefixResponder {
+	return &prefixResponder{session: s, message: m}
+}
+
+func (r *prefixResponder) Defer(ephemeral bool) error {
+	r.mu.Lock()
+	r.ephemeral = ephemeral
+	r.mu.Unlock()
+	return r.session.ChannelTyping(r.message.ChannelID)
+}
+
+func (r *prefixResponder) Respond(data *discordgo.InteractionResponseData) error {
+	return r.send(&discordgo.MessageSend{
+		Content:    data.Content,
+		Embeds:     data.Embeds,
+		Components: data.Components,
+		Files:      data.Files,
+	}, data.Flags&discordgo.MessageFlagsEphemeral != 0)
+}
+
+func (r *prefixResponder) Edit(edit *discordgo.WebhookEdit) error {
+	r.mu.Lock()
+	replyChannel, replyID, ephemeral := r.replyChannel, r.replyID, r.ephemeral
+	r.mu.Unlock()
+
+	if replyID == "" {
+		msg := &discordgo.MessageSend{Files: edit.Files}
+		if edit.Content != nil {
+			msg.Content = *edit.Content
+		}
+		if edit.Embeds != nil {
+			msg.Embeds = *edit.Embeds
+		}
+		if edit.Components != nil {
+			msg.Components = *edit.Components
+		}
+		return r.send(msg, ephemeral)
+	}
+
+	_, err := r.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:          replyID,
+		Channel:     replyChannel,
+		Content:     edit.Content,
+		Embeds:      edit.Embeds,
+		Components:  edit.Components,
+		Files:       edit.Files,
+		Attachments: edit.Attachments,
+	})
+	return err
+}
+
+func (r *prefixResponder) FollowUp(params *discordgo.WebhookParams) error {
+	msg := &discordgo.MessageSend{
+		Content:    params.Content,
+		Embeds:     params.Embeds,
+		Components: params.Components,
+		Files:      params.Files,
+	}
+	if params.Flags&discordgo.MessageFlagsEphemeral != 0 {
+		_, err := r.sendPrivate(msg)
+		return err
+	}
+	_, err := r.session.ChannelMessageSendComplex(r.message.ChannelID, msg)
+	return err
+}
+
+// send delivers the first reply, privately when ephemeral is set, and
+// records it for later edits.
+func (r *prefixResponder) send(msg *discordgo.MessageSend, ephemeral bool) error {
+	var (
+		sent *discordgo.Message
+		err  error
+	)
+	if ephemeral {
+		sent, err = r.sendPrivate(msg)
+	} else {
+		sent, err = r.sendReply(msg)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.replyChannel = sent.ChannelID
+	r.replyID = sent.ID
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *prefixResponder) sendReply(msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	msg.Reference = r.message.Reference()
+	msg.AllowedMentions = &discordgo.MessageAllowedMentions{}
+	return r.session.ChannelMessageSendComplex(r.message.ChannelID, msg)
+}
+
+// sendPrivate sends msg to the author by DM or, if that fails, as a reply
+// that is deleted after privateReplyLifetime.
+func (r *prefixResponder) sendPrivate(msg *discordgo.MessageSend) (*discordgo.Message, error) {
+	if r.message.GuildID == "" {
+		return r.sendReply(msg)
+	}
+
+	if dm, err := r.session.UserChannelCreate(r.message.Author.ID); err == nil {
+		if sent, err := r.session.ChannelMessageSendComplex(dm.ID, msg); err == nil {
+			return sent, nil
+		}
+	}
+
+	sent, err := r.sendReply(msg)
+	if err != nil {
+		return nil, err
+	}
+	time.AfterFunc(privateReplyLifetime, func() {
+		r.session.ChannelMessageDelete(sent.ChannelID, sent.ID)
+	})
+	return sent, nil
+}
@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeDiscord answers the REST requests of a session, recording the
+// messages sent to each channel.
+type fakeDiscord struct {
+	// closedDMs rejects messages sent to DM channels.
+	closedDMs bool
+
+	mu   sync.Mutex
+	sent map[string][]string
+}
+
+func newFakeSession(t *testing.T, f *fakeDiscord) *discordgo.Session {
+	t.Helper()
+	s, err := discordgo.New("Bot token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.sent = make(map[string][]string)
+	s.Client = &http.Client{Transport: f}
+	s.MaxRestRetries = 0
+	return s
+}
+
+func (f *fakeDiscord) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := strings.TrimPrefix(req.URL.Path, "/api/v"+discordgo.APIVersion)
+	respond := func(status int, body interface{}) (*http.Response, error) {
+		data, _ := json.Marshal(body)
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(string(data))),
+			Request:    req,
+		}, nil
+	}
+
+	switch {
+	case req.Method == http.MethodPost && path == "/users/@me/channels":
+		return respond(http.StatusOK, map[string]interface{}{"id": "dm", "type": discordgo.ChannelTypeDM})
+	case req.Method == http.MethodPost && strings.HasSuffix(path, "/messages"):
+		channelID := strings.Split(path, "/")[2]
+		if channelID == "dm" && f.closedDMs {
+			return respond(http.StatusForbidden, map[string]interface{}{"code": 50007, "message": "Cannot send messages to this user"})
+		}
+		var msg struct {
+			Content string `json:"content"`
+		}
+		json.NewDecoder(req.Body).Decode(&msg)
+
+		f.mu.Lock()
+		f.sent[channelID] = append(f.sent[channelID], msg.Content)
+		f.mu.Unlock()
+		return respond(http.StatusOK, map[string]interface{}{"id": "reply", "channel_id": channelID})
+	}
+	return respond(http.StatusNoContent, nil)
+}
+
+func (f *fakeDiscord) messages(channelID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sent[channelID]
+}
+
+func invokingMessage() *discordgo.Message {
+	return &discordgo.Message{
+		ID:        "invoke",
+		ChannelID: "channel",
+		GuildID:   "guild",
+		Author:    &discordgo.User{ID: "user"},
+	}
+}
+
+func TestPrefixResponderSendsEphemeralRepliesByDM(t *testing.T) {
+	f := &fakeDiscord{}
+	r := newPrefixResponder(newFakeSession(t, f), invokingMessage())
+
+	err := r.Respond(&discordgo.InteractionResponseData{Content: "privado", Flags: discordgo.MessageFlagsEphemeral})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.FollowUp(&discordgo.WebhookParams{Content: "também", Flags: discordgo.MessageFlagsEphemeral}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.FollowUp(&discordgo.WebhookParams{Content: "público"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := f.messages("dm"); len(got) != 2 || got[0] != "privado" || got[1] != "também" {
+		t.Errorf("DM got %q", got)
+	}
+	if got := f.messages("channel"); len(got) != 1 || got[0] != "público" {
+		t.Errorf("channel got %q", got)
+	}
+}
+
+func TestPrefixResponderDeferredEphemeralReply(t *testing.T) {
+	f := &fakeDiscord{}
+	r := newPrefixResponder(newFakeSession(t, f), invokingMessage())
+
+	if err := r.Defer(true); err != nil {
+		t.Fatal(err)
+	}
+	content := "lista"
+	if err := r.Edit(&discordgo.WebhookEdit{Content: &content}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := f.messages("dm"); len(got) != 1 || got[0] != "lista" {
+		t.Errorf("DM got %q", got)
+	}
+	if got := f.messages("channel"); len(got) != 0 {
+		t.Errorf("ephemeral reply was posted in the channel: %q", got)
+	}
+}
+
+func TestPrefixResponderFallsBackWhenDMsAreClosed(t *testing.T) {
+	f := &fakeDiscord{closedDMs: true}
+	r := newPrefixResponder(newFakeSession(t, f), invokingMessage())
+
+	err := r.Respond(&discordgo.InteractionResponseData{Content: "negado", Flags: discordgo.MessageFlagsEphemeral})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.messages("channel"); len(got) != 1 || got[0] != "negado" {
+		t.Errorf("channel got %q, want the reply that is deleted later", got)
+	}
+}
@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+func buildApplicationCommand(cmd *types.Command) (*discordgo.ApplicationCommand, error) {
+	if isContextMenu(cmd) {
+		if len(cmd.Options) > 0 || len(cmd.Subcommands) > 0 {
+			return nil, fmt.Errorf("context menu command %s cannot declare options or subcommands", cmd.Name)
+		}
+		return &discordgo.ApplicationCommand{
+			Name: cmd.Name,
+			Type: cmd.CommandType,
+		}, nil
+	}
+
+	command := &discordgo.ApplicationCommand{
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Type:        discordgo.ChatApplicationCommand,
+	}
+
+	if len(cmd.Subcommands) == 0 {
+		options, err := buildOptions(cmd)
+		if err != nil {
+			return nil, err
+		}
+		command.Options = options
+		return command, nil
+	}
+
+	if len(cmd.Options) > 0 || cmd.Run != nil {
+		return nil, fmt.Errorf("command %s has subcommands and cannot declare options or a handler", cmd.Name)
+	}
+
+	for _, sub := range cmd.Subcommands {
+		if len(sub.Subcommands) == 0 {
+			option, err := buildSubcommand(sub)
+			if err != nil {
+				return nil, err
+			}
+			command.Options = append(command.Options, option)
+			continue
+		}
+
+		group := &discordgo.ApplicationCommandOption{
+			Name:        sub.Name,
+			Description: sub.Description,
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+		}
+		for _, leaf := range sub.Subcommands {
+			if len(leaf.Subcommands) > 0 {
+				return nil, fmt.Errorf("command %s nests subcommands deeper than a group", cmd.Name)
+			}
+			option, err := buildSubcommand(leaf)
+			if err != nil {
+				return nil, err
+			}
+			group.Options = append(group.Options, option)
+		}
+		command.Options = append(command.Options, group)
+	}
+
+	return command, nil
+}
+
+func buildSubcommand(cmd *types.Command) (*discordgo.ApplicationCommandOption, error) {
+	options, err := buildOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return &discordgo.ApplicationCommandOption{
+		Name:        cmd.Name,
+		Description: cmd.Description,
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Options:     options,
+	}, nil
+}
+
+func buildOptions(cmd *types.Command) ([]*discordgo.ApplicationCommandOption, error) {
+	options := make([]*discordgo.ApplicationCommandOption, 0, len(cmd.Options))
+	for _, opt := range cmd.Options {
+		if err := validateOption(cmd, opt); err != nil {
+			return nil, fmt.Errorf("command %s, option %s: %v", cmd.Name, opt.Name, err)
+		}
+		options = append(options, &discordgo.ApplicationCommandOption{
+			Name:         opt.Name,
+			Description:  opt.Description,
+			Type:         opt.Type,
+			Required:     opt.Required,
+			Choices:      opt.Choices,
+			MinValue:     opt.MinValue,
+			MaxValue:     opt.MaxValue,
+			MinLength:    opt.MinLength,
+			MaxLength:    opt.MaxLength,
+			ChannelTypes: opt.ChannelTypes,
+			Autocomplete: opt.Autocomplete,
+		})
+	}
+	return options, nil
+}
+
+// validateOption rejects constraints Discord would refuse when the commands
+// are registered, so the mistake is reported with the command that caused it.
+func validateOption(cmd *types.Command, opt *types.CommandOption) error {
+	numeric := opt.Type == discordgo.ApplicationCommandOptionInteger || opt.Type == discordgo.ApplicationCommandOptionNumber
+
+	if (opt.MinValue != nil || opt.MaxValue != 0) && !numeric {
+		return fmt.Errorf("min/max value is only valid for integer and number options")
+	}
+	if opt.MinValue != nil && opt.MaxValue != 0 && *opt.MinValue > opt.MaxValue {
+		return fmt.Errorf("min value %v is greater than max value %v", *opt.MinValue, opt.MaxValue)
+	}
+
+	if opt.MinLength != nil || opt.MaxLength != 0 {
+		if opt.Type != discordgo.ApplicationCommandOptionString {
+			return fmt.Errorf("min/max length is only valid for string options")
+		}
+		if opt.MinLength != nil && (*opt.MinLength < 0 || *opt.MinLength > 6000) {
+			return fmt.Errorf("min length must be between 0 and 6000")
+		}
+		if opt.MaxLength < 0 || opt.MaxLength > 6000 {
+			return fmt.Errorf("max length must be between 1 and 6000")
+		}
+		if opt.MinLength != nil && opt.MaxLength != 0 && *opt.MinLength > opt.MaxLength {
+			return fmt.Errorf("min length %d is greater than max length %d", *opt.MinLength, opt.MaxLength)
+		}
+	}
+
+	if len(opt.ChannelTypes) > 0 && opt.Type != discordgo.ApplicationCommandOptionChannel {
+		return fmt.Errorf("channel types are only valid for channel options")
+	}
+
+	if opt.Autocomplete {
+		if len(opt.Choices) > 0 {
+			return fmt.Errorf("autocomplete cannot be combined with choices")
+		}
+		if opt.Type != discordgo.ApplicationCommandOptionString && !numeric {
+			return fmt.Errorf("autocomplete is only valid for string, integer and number options")
+		}
+		if cmd.AutoComplete == nil {
+			return fmt.Errorf("autocomplete is enabled but the command has no AutoComplete handler")
+		}
+	}
+	return nil
+}
+
+// applyArgs returns cmd with the options of it and its subcommands
+// generated from their Args struct, replacing any declared Options, so the
+// schema registered with Discord and the decoded struct cannot drift apart.
+// Commands with Args are copied: the registered ones are shared by every
+// reload and must not change.
+func applyArgs(cmd *types.Command) (*types.Command, error) {
+	if cmd.Args == nil && len(cmd.Subcommands) == 0 {
+		return cmd, nil
+	}
+
+	built := *cmd
+	if cmd.Args != nil {
+		options, err := types.OptionsFromArgs(cmd.Args)
+		if err != nil {
+			return nil, fmt.Errorf("command %s: %v", cmd.Name, err)
+		}
+		built.Options = options
+	}
+
+	if len(cmd.Subcommands) > 0 {
+		built.Subcommands = make([]*types.Command, len(cmd.Subcommands))
+		for i, sub := range cmd.Subcommands {
+			applied, err := applyArgs(sub)
+			if err != nil {
+				return nil, err
+			}
+			built.Subcommands[i] = applied
+		}
+	}
+	return &built, nil
+}
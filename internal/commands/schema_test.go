@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+type resizeArgs struct {
+	Width  int64  `option:"largura" description:"A largura" required:"true" min:"1" max:"4000"`
+	Format string `option:"formato" description:"O formato" choices:"PNG=png;JPEG=jpeg"`
+}
+
+func TestApplyArgsLeavesRegisteredCommandUntouched(t *testing.T) {
+	leaf := &types.Command{Name: "resize", Description: "Redimensiona", Args: resizeArgs{}}
+	registered := &types.Command{
+		Name:        "image",
+		Description: "Imagens",
+		Subcommands: []*types.Command{leaf},
+	}
+
+	for reload := 0; reload < 2; reload++ {
+		cmd, err := applyArgs(registered)
+		if err != nil {
+			t.Fatalf("applyArgs() = %v", err)
+		}
+		if cmd == registered || cmd.Subcommands[0] == leaf {
+			t.Fatal("applyArgs() returned the registered command")
+		}
+		if got := len(cmd.Subcommands[0].Options); got != 2 {
+			t.Fatalf("reload %d: subcommand has %d options, want 2", reload, got)
+		}
+
+		command, err := buildApplicationCommand(cmd)
+		if err != nil {
+			t.Fatalf("buildApplicationCommand() = %v", err)
+		}
+		sub := command.Options[0]
+		if sub.Type != discordgo.ApplicationCommandOptionSubCommand || len(sub.Options) != 2 {
+			t.Fatalf("reload %d: built subcommand %+v", reload, sub)
+		}
+	}
+
+	if leaf.Options != nil {
+		t.Errorf("registered subcommand options = %v, want nil", leaf.Options)
+	}
+}
+
+func TestApplyArgsWithoutArgs(t *testing.T) {
+	cmd := &types.Command{Name: "ping", Description: "Pong"}
+
+	applied, err := applyArgs(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != cmd {
+		t.Error("commands without Args are copied needlessly")
+	}
+}
+
+func TestApplyArgsRejectsBadTags(t *testing.T) {
+	type badArgs struct {
+		Size int64 `option:"tamanho" description:"O tamanho" min:"dez"`
+	}
+	cmd := &types.Command{Name: "bad", Description: "Ruim", Args: badArgs{}}
+
+	if _, err := applyArgs(cmd); err == nil {
+		t.Fatal("applyArgs() = nil, want an error for the invalid min")
+	}
+}
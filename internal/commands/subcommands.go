@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/types"
+)
+
+// flattenCommand returns the executable commands reachable from cmd keyed by
+// their full invocation path, e.g. "image resize". Each leaf is a copy whose
+// Name is the full path and which inherits restrictions, cooldown, timeout
+// and middleware from its parents.
+func flattenCommand(cmd *types.Command) map[string]*types.Command {
+	leaves := make(map[string]*types.Command)
+	if isContextMenu(cmd) {
+		resolved := *cmd
+		resolved.Run = contextMenuRun(cmd)
+		leaves[commandKey(cmd.CommandType, cmd.Name)] = &resolved
+		return leaves
+	}
+	collectLeaves(cmd, nil, leaves)
+	return leaves
+}
+
+func collectLeaves(cmd *types.Command, parent *types.Command, leaves map[string]*types.Command) {
+	resolved := *cmd
+	if parent != nil {
+		resolved.Name = parent.Name + " " + cmd.Name
+		resolved.DevOnly = cmd.DevOnly || parent.DevOnly
+		resolved.AdminOnly = cmd.AdminOnly || parent.AdminOnly
+		resolved.AllowPrefix = cmd.AllowPrefix || parent.AllowPrefix
+		resolved.Intents = cmd.Intents | parent.Intents
+		resolved.Requires = append(append([]string{}, parent.Requires...), cmd.Requires...)
+		resolved.Middlewares = append(append([]types.Middleware{}, parent.Middlewares...), cmd.Middlewares...)
+		if resolved.Cooldown == 0 {
+			resolved.Cooldown = parent.Cooldown
+		}
+		if resolved.Timeout == 0 {
+			resolved.Timeout = parent.Timeout
+		}
+		if resolved.Category == "" {
+			resolved.Category = parent.Category
+		}
+	}
+
+	if len(cmd.Subcommands) == 0 {
+		leaves[resolved.Name] = &resolved
+		return
+	}
+
+	for _, sub := range cmd.Subcommands {
+		collectLeaves(sub, &resolved, leaves)
+	}
+}
+
+// commandPath returns the full invocation path of the interaction, matching
+// the keys produced by flattenCommand.
+func commandPath(data discordgo.ApplicationCommandInteractionData) string {
+	path := []string{data.Name}
+	options := data.Options
+	for len(options) > 0 {
+		opt := options[0]
+		if opt.Type != discordgo.ApplicationCommandOptionSubCommand &&
+			opt.Type != discordgo.ApplicationCommandOptionSubCommandGroup {
+			break
+		}
+		path = append(path, opt.Name)
+		options = opt.Options
+	}
+	return strings.Join(path, " ")
+}
+
+// enabledCommand returns cmd without the subcommands whose Requires name a
+// disabled feature, or false if nothing runnable is left.
+func enabledCommand(cfg *config.Config, cmd *types.Command) (*types.Command, bool) {
+	if !cfg.Enabled(cmd.Requires...) {
+		return nil, false
+	}
+	if len(cmd.Subcommands) == 0 {
+		return cmd, true
+	}
+
+	subcommands := make([]*types.Command, 0, len(cmd.Subcommands))
+	for _, sub := range cmd.Subcommands {
+		if enabled, ok := enabledCommand(cfg, sub); ok {
+			subcommands = append(subcommands, enabled)
+		}
+	}
+	if len(subcommands) == 0 {
+		return nil, false
+	}
+	if len(subcommands) == len(cmd.Subcommands) {
+		return cmd, true
+	}
+
+	filtered := *cmd
+	filtered.Subcommands = subcommands
+	return &filtered, true
+}
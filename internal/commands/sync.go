@@ -0,0 +1,278 @@
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/models"
+)
+
+type syncPlan struct {
+	create []*discordgo.ApplicationCommand
+	update []*discordgo.ApplicationCommand
+	delete []*discordgo.ApplicationCommand
+}
+
+func (p *syncPlan) empty() bool {
+	return len(p.create) == 0 && len(p.update) == 0 && len(p.delete) == 0
+}
+
+// commandScopes returns the guild IDs commands are registered to, where an
+// empty ID stands for global registration. Without an explicit
+// configuration the legacy Discord.GuildID setting is honoured.
+func (h *Handler) commandScopes() []string {
+	cfg := h.currentConfig().Discord.Commands
+	if !cfg.Global && len(cfg.DevGuilds) == 0 {
+		return []string{h.currentConfig().Discord.GuildID}
+	}
+
+	var scopes []string
+	if cfg.Global {
+		scopes = append(scopes, "")
+	}
+	return append(scopes, cfg.DevGuilds...)
+}
+
+// syncCommands brings the registered commands of every scope in line with
+// desired, only creating, editing or deleting the commands that differ so
+// unchanged commands keep their IDs. Scopes that commands were registered to
+// before, in this process or a previous one, but are no longer configured
+// are cleared.
+func (h *Handler) syncCommands(desired []*discordgo.ApplicationCommand) error {
+	h.syncMutex.Lock()
+	defer h.syncMutex.Unlock()
+
+	appID := h.currentConfig().Discord.ClientID
+	dryRun := h.currentConfig().Discord.Commands.DryRun
+	scopes := h.commandScopes()
+
+	for _, guildID := range scopes {
+		if err := h.syncScope(appID, guildID, desired, dryRun); err != nil {
+			return err
+		}
+	}
+
+	for _, guildID := range staleScopes(h.previousScopes(appID), scopes) {
+		// The bot may have left the guild, so a failure here does not stop
+		// the sync.
+		if err := h.syncScope(appID, guildID, nil, dryRun); err != nil {
+			h.logger.Warn("Failed to clear commands of a scope no longer configured", "scope", scopeName(guildID), "error", err)
+		}
+	}
+
+	if dryRun {
+		h.logger.Info("Command sync dry run enabled, no changes were applied")
+		return nil
+	}
+
+	h.syncedScopes = scopes
+	if h.db != nil {
+		err := h.db.SaveCommandScopes(&models.CommandScopes{ApplicationID: appID, GuildIDs: scopes, SyncedAt: time.Now()})
+		if err != nil {
+			h.logger.Error("Failed to save command scopes", "error", err)
+		}
+	}
+	return nil
+}
+
+// syncScope brings the commands registered to guildID, or the global ones
+// when it is empty, in line with desired.
+func (h *Handler) syncScope(appID, guildID string, desired []*discordgo.ApplicationCommand, dryRun bool) error {
+	scope := scopeName(guildID)
+
+	existing, err := h.session.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s commands: %v", scope, err)
+	}
+
+	plan := diffCommands(existing, desired)
+	if plan.empty() {
+		h.logger.Info("Commands are up to date", "scope", scope)
+		return nil
+	}
+
+	h.logger.Info("Command sync plan", "scope", scope, "create", commandNames(plan.create),
+		"update", commandNames(plan.update), "delete", commandNames(plan.delete))
+	if dryRun {
+		return nil
+	}
+
+	for _, cmd := range plan.create {
+		if _, err := h.session.ApplicationCommandCreate(appID, guildID, cmd); err != nil {
+			return fmt.Errorf("failed to create command %s in %s: %v", cmd.Name, scope, err)
+		}
+	}
+	for _, cmd := range plan.update {
+		if _, err := h.session.ApplicationCommandEdit(appID, guildID, cmd.ID, cmd); err != nil {
+			return fmt.Errorf("failed to update command %s in %s: %v", cmd.Name, scope, err)
+		}
+	}
+	for _, cmd := range plan.delete {
+		if err := h.session.ApplicationCommandDelete(appID, guildID, cmd.ID); err != nil {
+			return fmt.Errorf("failed to delete command %s in %s: %v", cmd.Name, scope, err)
+		}
+	}
+	return nil
+}
+
+// previousScopes returns the scopes commands were registered to by this
+// process and, as recorded in the database, by previous ones.
+func (h *Handler) previousScopes(appID string) []string {
+	scopes := append([]string(nil), h.syncedScopes...)
+	if h.db == nil {
+		return scopes
+	}
+
+	saved, err := h.db.GetCommandScopes(appID)
+	if err != nil {
+		h.logger.Error("Failed to load command scopes", "error", err)
+		return scopes
+	}
+	if saved != nil {
+		scopes = append(scopes, saved.GuildIDs...)
+	}
+	return scopes
+}
+
+// staleScopes returns the scopes of previous that are not in current,
+// without duplicates.
+func staleScopes(previous, current []string) []string {
+	seen := make(map[string]bool, len(current))
+	for _, guildID := range current {
+		seen[guildID] = true
+	}
+
+	var stale []string
+	for _, guildID := range previous {
+		if !seen[guildID] {
+			seen[guildID] = true
+			stale = append(stale, guildID)
+		}
+	}
+	return stale
+}
+
+func scopeName(guildID string) string {
+	if guildID == "" {
+		return "global"
+	}
+	return "guild " + guildID
+}
+
+func diffCommands(existing, desired []*discordgo.ApplicationCommand) *syncPlan {
+	plan := &syncPlan{}
+
+	current := make(map[string]*discordgo.ApplicationCommand, len(existing))
+	for _, cmd := range existing {
+		current[syncKey(cmd)] = cmd
+	}
+
+	for _, cmd := range desired {
+		key := syncKey(cmd)
+		old, ok := current[key]
+		delete(current, key)
+
+		switch {
+		case !ok:
+			plan.create = append(plan.create, cmd)
+		case !reflect.DeepEqual(canonicalCommand(old), canonicalCommand(cmd)):
+			updated := *cmd
+			updated.ID = old.ID
+			plan.update = append(plan.update, &updated)
+		}
+	}
+
+	for _, cmd := range current {
+		plan.delete = append(plan.delete, cmd)
+	}
+	sort.Slice(plan.delete, func(a, b int) bool { return plan.delete[a].Name < plan.delete[b].Name })
+
+	return plan
+}
+
+func syncKey(cmd *discordgo.ApplicationCommand) string {
+	t := cmd.Type
+	if t == 0 {
+		t = discordgo.ChatApplicationCommand
+	}
+	return fmt.Sprintf("%d:%s", t, cmd.Name)
+}
+
+func commandNames(cmds []*discordgo.ApplicationCommand) string {
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// commandSpec and optionSpec hold the fields that define a command's schema
+// in a form where values read back from the API and values built locally
+// compare equal.
+type commandSpec struct {
+	Key         string
+	Description string
+	Options     []optionSpec
+}
+
+type optionSpec struct {
+	Type         discordgo.ApplicationCommandOptionType
+	Name         string
+	Description  string
+	Required     bool
+	Autocomplete bool
+	Choices      []string
+	ChannelTypes []int
+	MinValue     string
+	MaxValue     float64
+	MinLength    string
+	MaxLength    int
+	Options      []optionSpec
+}
+
+func canonicalCommand(cmd *discordgo.ApplicationCommand) commandSpec {
+	return commandSpec{
+		Key:         syncKey(cmd),
+		Description: cmd.Description,
+		Options:     canonicalOptions(cmd.Options),
+	}
+}
+
+func canonicalOptions(opts []*discordgo.ApplicationCommandOption) []optionSpec {
+	if len(opts) == 0 {
+		return nil
+	}
+
+	specs := make([]optionSpec, 0, len(opts))
+	for _, opt := range opts {
+		spec := optionSpec{
+			Type:         opt.Type,
+			Name:         opt.Name,
+			Description:  opt.Description,
+			Required:     opt.Required,
+			Autocomplete: opt.Autocomplete,
+			MaxValue:     opt.MaxValue,
+			MaxLength:    opt.MaxLength,
+			Options:      canonicalOptions(opt.Options),
+		}
+		for _, c := range opt.Choices {
+			spec.Choices = append(spec.Choices, fmt.Sprintf("%s=%v", c.Name, c.Value))
+		}
+		for _, ct := range opt.ChannelTypes {
+			spec.ChannelTypes = append(spec.ChannelTypes, int(ct))
+		}
+		sort.Ints(spec.ChannelTypes)
+		if opt.MinValue != nil {
+			spec.MinValue = fmt.Sprint(*opt.MinValue)
+		}
+		if opt.MinLength != nil {
+			spec.MinLength = fmt.Sprint(*opt.MinLength)
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
@@ -0,0 +1,199 @@
+package commands
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/logger"
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestCanonicalCommandIgnoresAPIOnlyFields(t *testing.T) {
+	local := &discordgo.ApplicationCommand{
+		Name:        "ping",
+		Description: "Pong",
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         "canal",
+			Description:  "O canal",
+			ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildText},
+			MinValue:     float(1),
+		}},
+	}
+	remote := &discordgo.ApplicationCommand{
+		ID:            "123",
+		ApplicationID: "app",
+		Version:       "456",
+		Type:          discordgo.ChatApplicationCommand,
+		Name:          "ping",
+		Description:   "Pong",
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         "canal",
+			Description:  "O canal",
+			ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews},
+			MinValue:     float(1),
+		}},
+	}
+
+	if a, b := canonicalCommand(local), canonicalCommand(remote); syncKey(local) != syncKey(remote) || !reflect.DeepEqual(a, b) {
+		t.Errorf("canonicalCommand() differs:\n%+v\n%+v", a, b)
+	}
+
+	remote.Options[0].MinValue = float(2)
+	if reflect.DeepEqual(canonicalCommand(local), canonicalCommand(remote)) {
+		t.Error("canonicalCommand() ignores a changed min value")
+	}
+}
+
+func TestDiffCommands(t *testing.T) {
+	existing := []*discordgo.ApplicationCommand{
+		{ID: "1", Name: "ping", Description: "Pong"},
+		{ID: "2", Name: "help", Description: "Ajuda antiga"},
+		{ID: "3", Name: "old", Description: "Removido"},
+		{ID: "4", Type: discordgo.UserApplicationCommand, Name: "info"},
+	}
+	desired := []*discordgo.ApplicationCommand{
+		{Name: "ping", Description: "Pong"},
+		{Name: "help", Description: "Ajuda"},
+		{Name: "new", Description: "Novo"},
+		// Same name as the user command, but a different type.
+		{Name: "info", Description: "Informações"},
+	}
+
+	plan := diffCommands(existing, desired)
+
+	if got := commandNames(plan.create); got != "new, info" {
+		t.Errorf("create = %q", got)
+	}
+	if len(plan.update) != 1 || plan.update[0].Name != "help" || plan.update[0].ID != "2" {
+		t.Errorf("update = %+v, want help with its existing ID", plan.update)
+	}
+	if desired[1].ID != "" {
+		t.Error("diffCommands() changed the desired command")
+	}
+	if got := commandNames(plan.delete); got != "info, old" {
+		t.Errorf("delete = %q", got)
+	}
+
+	if plan := diffCommands(existing[:1], desired[:1]); !plan.empty() {
+		t.Errorf("unchanged commands produced a plan: %+v", plan)
+	}
+}
+
+func TestStaleScopes(t *testing.T) {
+	tests := []struct {
+		previous, current, want []string
+	}{
+		{nil, []string{""}, nil},
+		{[]string{"old"}, []string{""}, []string{"old"}},
+		{[]string{"", "a", "a"}, []string{"b"}, []string{"", "a"}},
+		{[]string{"a"}, []string{"a"}, nil},
+	}
+	for _, tt := range tests {
+		got := staleScopes(tt.previous, tt.current)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || len(got) != len(tt.want) {
+			t.Errorf("staleScopes(%q, %q) = %q, want %q", tt.previous, tt.current, got, tt.want)
+		}
+	}
+}
+
+// fakeCommandAPI serves the application commands of each scope.
+type fakeCommandAPI struct {
+	mu       sync.Mutex
+	commands map[string][]*discordgo.ApplicationCommand
+	deleted  []string
+}
+
+func (f *fakeCommandAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	// /applications/app/commands or /applications/app/guilds/ID/commands[/CMD]
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/api/v"+discordgo.APIVersion+"/"), "/")
+	scope := ""
+	if len(parts) > 3 && parts[2] == "guilds" {
+		scope = parts[3]
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var body interface{}
+	switch req.Method {
+	case http.MethodGet:
+		cmds := f.commands[scope]
+		if cmds == nil {
+			cmds = []*discordgo.ApplicationCommand{}
+		}
+		body = cmds
+	case http.MethodPost:
+		var cmd discordgo.ApplicationCommand
+		json.NewDecoder(req.Body).Decode(&cmd)
+		cmd.ID = cmd.Name
+		f.commands[scope] = append(f.commands[scope], &cmd)
+		body = cmd
+	case http.MethodDelete:
+		f.deleted = append(f.deleted, scope+"/"+parts[len(parts)-1])
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+	}
+
+	data, _ := json.Marshal(body)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(string(data))),
+		Request:    req,
+	}, nil
+}
+
+func TestSyncCommandsClearsRemovedScopes(t *testing.T) {
+	api := &fakeCommandAPI{commands: map[string][]*discordgo.ApplicationCommand{
+		"old": {{ID: "9", Name: "ping", Description: "Pong"}},
+	}}
+	s, err := discordgo.New("Bot token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Client = &http.Client{Transport: api}
+	s.MaxRestRetries = 0
+
+	var cfg config.Config
+	cfg.Logger.File = filepath.Join(t.TempDir(), "commands.log")
+	cfg.Logger.Level = "error"
+	cfg.Discord.ClientID = "app"
+	cfg.Discord.GuildID = "old"
+
+	h := &Handler{session: s, logger: logger.New(&cfg)}
+	h.config.Store(&cfg)
+	desired := []*discordgo.ApplicationCommand{{Name: "ping", Description: "Pong"}}
+
+	if err := h.syncCommands(desired); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.deleted) != 0 {
+		t.Fatalf("deleted %v from the configured guild", api.deleted)
+	}
+
+	// Moving to global registration clears the guild registered before.
+	next := cfg
+	next.Discord.GuildID = ""
+	next.Discord.Commands.Global = true
+	h.SetConfig(&next)
+
+	if err := h.syncCommands(desired); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.deleted) != 1 || api.deleted[0] != "old/9" {
+		t.Errorf("deleted %v, want old/9", api.deleted)
+	}
+	if len(api.commands[""]) != 1 {
+		t.Errorf("global commands = %d, want 1", len(api.commands[""]))
+	}
+}
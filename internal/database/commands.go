@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kevinfinalboss/Void/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (db *MongoDB) SaveCommandScopes(scopes *models.CommandScopes) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("command_scopes")
+
+	opts := options.Replace().SetUpsert(true)
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": scopes.ApplicationID}, scopes, opts)
+	return err
+}
+
+// GetCommandScopes returns where the commands of the application were last
+// registered, or nil if they never were.
+func (db *MongoDB) GetCommandScopes(applicationID string) (*models.CommandScopes, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("command_scopes")
+
+	var scopes models.CommandScopes
+	err := collection.FindOne(ctx, bson.M{"_id": applicationID}).Decode(&scopes)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &scopes, nil
+}
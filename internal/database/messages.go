@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kevinfinalboss/Void/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureMessageIndexes creates the indexes of the messages collection.
+// Documents are removed by MongoDB once they are older than window.
+func (db *MongoDB) EnsureMessageIndexes(window time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("messages")
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "message_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(window.Seconds())),
+		},
+	})
+	return err
+}
+
+func (db *MongoDB) SaveCachedMessage(message *models.CachedMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("messages")
+
+	filter := bson.M{"message_id": message.MessageID}
+	update := bson.M{"$set": message}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := collection.UpdateOne(ctx, filter, update, opts)
+	return err
+}
+
+// GetCachedMessage returns the stored snapshot of a message, or nil if there
+// is none.
+func (db *MongoDB) GetCachedMessage(messageID string) (*models.CachedMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("messages")
+
+	var message models.CachedMessage
+	err := collection.FindOne(ctx, bson.M{"message_id": messageID}).Decode(&message)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
@@ -2,8 +2,10 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/kevinfinalboss/Void/config"
@@ -13,9 +15,23 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const settingsCacheTTL = 5 * time.Minute
+
 type MongoDB struct {
 	client   *mongo.Client
 	database string
+
+	settingsMu    sync.RWMutex
+	settingsCache map[string]cachedSettings
+	// settingsSwept is when expired settings were last dropped from
+	// settingsCache, which would otherwise keep every guild ever read.
+	settingsSwept   time.Time
+	settingsChanged func(guildID string)
+}
+
+type cachedSettings struct {
+	settings  models.GuildSettings
+	fetchedAt time.Time
 }
 
 func NewMongoDB(cfg *config.Config) (*MongoDB, error) {
@@ -36,19 +52,49 @@ func NewMongoDB(cfg *config.Config) (*MongoDB, error) {
 	}
 
 	return &MongoDB{
-		client:   client,
-		database: cfg.MongoDB.Database,
+		client:        client,
+		database:      cfg.MongoDB.Database,
+		settingsCache: make(map[string]cachedSettings),
 	}, nil
 }
 
+// UpsertGuild stores the gateway data of a guild. JoinedAt is only written
+// when the document is created and the settings are never touched, so
+// reconnects do not reset them. A zero MemberCount is left unchanged, since
+// guild updates do not carry it.
 func (db *MongoDB) UpsertGuild(guild *models.Guild) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	collection := db.client.Database(db.database).Collection("guilds")
 
+	joinedAt := guild.JoinedAt
+	if joinedAt.IsZero() {
+		joinedAt = time.Now()
+	}
+
+	set := bson.M{
+		"name":         guild.Name,
+		"owner_id":     guild.OwnerID,
+		"is_active":    guild.IsActive,
+		"region":       guild.Region,
+		"icon":         guild.Icon,
+		"features":     guild.Features,
+		"last_updated": guild.LastUpdated,
+	}
+	if guild.MemberCount > 0 {
+		set["member_count"] = guild.MemberCount
+	}
+
+	update := bson.M{
+		"$set":         set,
+		"$setOnInsert": bson.M{"joined_at": joinedAt},
+	}
+	if guild.IsActive {
+		update["$unset"] = bson.M{"left_at": ""}
+	}
+
 	filter := bson.M{"guild_id": guild.GuildID}
-	update := bson.M{"$set": guild}
 	opts := options.Update().SetUpsert(true)
 
 	_, err := collection.UpdateOne(ctx, filter, update, opts)
@@ -73,6 +119,71 @@ func (db *MongoDB) UpdateGuildStatus(guildID string, isActive bool, leftAt *time
 	return err
 }
 
+// ActiveGuildIDs returns the IDs of the guilds stored as active.
+func (db *MongoDB) ActiveGuildIDs() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("guilds")
+
+	opts := options.Find().SetProjection(bson.M{"guild_id": 1})
+	cursor, err := collection.Find(ctx, bson.M{"is_active": true}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ids []string
+	for cursor.Next(ctx) {
+		var guild models.Guild
+		if err := cursor.Decode(&guild); err != nil {
+			return nil, err
+		}
+		ids = append(ids, guild.GuildID)
+	}
+	return ids, cursor.Err()
+}
+
+// MarkGuildsInactive flags the given guilds as left at leftAt.
+func (db *MongoDB) MarkGuildsInactive(guildIDs []string, leftAt time.Time) error {
+	if len(guildIDs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("guilds")
+
+	update := bson.M{
+		"$set": bson.M{
+			"is_active":    false,
+			"left_at":      leftAt,
+			"last_updated": time.Now(),
+		},
+	}
+
+	_, err := collection.UpdateMany(ctx, bson.M{"guild_id": bson.M{"$in": guildIDs}}, update)
+	return err
+}
+
+func (db *MongoDB) SetMemberCount(guildID string, count int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("guilds")
+
+	update := bson.M{
+		"$set": bson.M{
+			"member_count": count,
+			"last_updated": time.Now(),
+		},
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"guild_id": guildID}, update)
+	return err
+}
+
 func (db *MongoDB) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -102,6 +213,52 @@ func (db *MongoDB) UpdateMemberCount(guildID string, delta int) error {
 	return err
 }
 
+// GetGuildSettings returns the settings of a guild, served from a short
+// lived cache since it is read on hot paths such as message handling. In a
+// cluster, changes made by other workers drop the cached copy, see
+// OnSettingsChanged. Guilds without a document get zero-value settings.
+func (db *MongoDB) GetGuildSettings(guildID string) (models.GuildSettings, error) {
+	db.settingsMu.RLock()
+	cached, ok := db.settingsCache[guildID]
+	db.settingsMu.RUnlock()
+	if ok && time.Since(cached.fetchedAt) < settingsCacheTTL {
+		return cached.settings, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("guilds")
+
+	var guild models.Guild
+	opts := options.FindOne().SetProjection(bson.M{"settings": 1})
+	err := collection.FindOne(ctx, bson.M{"guild_id": guildID}, opts).Decode(&guild)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return models.GuildSettings{}, err
+	}
+
+	db.settingsMu.Lock()
+	db.settingsCache[guildID] = cachedSettings{settings: guild.Settings, fetchedAt: time.Now()}
+	db.sweepSettings()
+	db.settingsMu.Unlock()
+
+	return guild.Settings, nil
+}
+
+// sweepSettings drops the expired settings from the cache, at most once per
+// TTL. db.settingsMu must be held.
+func (db *MongoDB) sweepSettings() {
+	if time.Since(db.settingsSwept) < settingsCacheTTL {
+		return
+	}
+	for guildID, cached := range db.settingsCache {
+		if time.Since(cached.fetchedAt) >= settingsCacheTTL {
+			delete(db.settingsCache, guildID)
+		}
+	}
+	db.settingsSwept = time.Now()
+}
+
 func (db *MongoDB) UpdateGuildSettings(guildID string, setting string, value interface{}) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -120,5 +277,33 @@ func (db *MongoDB) UpdateGuildSettings(guildID string, setting string, value int
 		bson.M{"guild_id": guildID},
 		update,
 	)
+
+	db.settingsMu.Lock()
+	delete(db.settingsCache, guildID)
+	changed := db.settingsChanged
+	db.settingsMu.Unlock()
+
+	if err == nil && changed != nil {
+		changed(guildID)
+	}
 	return err
 }
+
+// OnSettingsChanged sets a function called with the guild whenever
+// UpdateGuildSettings changes its settings, so that the other processes of
+// a cluster can drop their cached copy.
+func (db *MongoDB) OnSettingsChanged(fn func(guildID string)) {
+	db.settingsMu.Lock()
+	db.settingsChanged = fn
+	db.settingsMu.Unlock()
+}
+
+// InvalidateGuildSettings drops the cached settings of the guilds, after
+// another process changed them or the bot left the guilds.
+func (db *MongoDB) InvalidateGuildSettings(guildIDs ...string) {
+	db.settingsMu.Lock()
+	for _, guildID := range guildIDs {
+		delete(db.settingsCache, guildID)
+	}
+	db.settingsMu.Unlock()
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSweepSettings(t *testing.T) {
+	db := &MongoDB{settingsCache: map[string]cachedSettings{
+		"old":   {fetchedAt: time.Now().Add(-2 * settingsCacheTTL)},
+		"fresh": {fetchedAt: time.Now()},
+	}}
+
+	db.sweepSettings()
+	if _, ok := db.settingsCache["old"]; ok {
+		t.Error("expired settings were kept")
+	}
+	if _, ok := db.settingsCache["fresh"]; !ok {
+		t.Error("fresh settings were dropped")
+	}
+
+	// A second sweep within the TTL leaves the cache alone.
+	db.settingsCache["old"] = cachedSettings{fetchedAt: time.Now().Add(-2 * settingsCacheTTL)}
+	db.sweepSettings()
+	if _, ok := db.settingsCache["old"]; !ok {
+		t.Error("settings were swept twice within the TTL")
+	}
+}
+
+func TestInvalidateGuildSettings(t *testing.T) {
+	db := &MongoDB{settingsCache: map[string]cachedSettings{
+		"a": {fetchedAt: time.Now()},
+		"b": {fetchedAt: time.Now()},
+	}}
+
+	db.InvalidateGuildSettings("a")
+	if _, ok := db.settingsCache["a"]; ok {
+		t.Error("invalidated settings were kept")
+	}
+	if _, ok := db.settingsCache["b"]; !ok {
+		t.Error("settings of another guild were dropped")
+	}
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kevinfinalboss/Void/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (db *MongoDB) SaveGatewaySession(session *models.GatewaySession) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("gateway_sessions")
+
+	opts := options.Replace().SetUpsert(true)
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": session.ShardID}, session, opts)
+	return err
+}
+
+// TakeGatewaySession removes and returns the saved session of a shard, or
+// nil if there is none. A session can only be resumed once, so it is never
+// handed out twice.
+func (db *MongoDB) TakeGatewaySession(shardID int) (*models.GatewaySession, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := db.client.Database(db.database).Collection("gateway_sessions")
+
+	var session models.GatewaySession
+	err := collection.FindOneAndDelete(ctx, bson.M{"_id": shardID}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -2,115 +2,162 @@ package events
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
+	"reflect"
+	"runtime/debug"
+	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/kevinfinalboss/Void/config"
+	_ "github.com/kevinfinalboss/Void/events/all"
 	"github.com/kevinfinalboss/Void/internal/logger"
+	"github.com/kevinfinalboss/Void/internal/registry"
 	"github.com/kevinfinalboss/Void/internal/types"
 )
 
+// Handler dispatches the events in registry.Events, together with the
+// bot's default events, to the sessions passed to LoadEvents.
 type Handler struct {
-	events    map[string]*types.Event
-	session   *discordgo.Session
-	config    *config.Config
-	logger    *logger.Logger
-	readyOnce sync.Once
-}
+	config   atomic.Pointer[config.Config]
+	logger   *logger.Logger
+	disabled atomic.Int64
 
-func NewHandler(s *discordgo.Session, cfg *config.Config, l *logger.Logger) *Handler {
-	return &Handler{
-		events:  make(map[string]*types.Event),
-		session: s,
-		config:  cfg,
-		logger:  l,
-	}
+	mu     sync.RWMutex
+	events map[reflect.Type][]*types.Event
 }
 
-func (h *Handler) LoadEvents() error {
-	h.logger.Info("Loading events...")
-	err := h.loadEventFiles()
-	if err != nil {
-		return fmt.Errorf("error loading event files: %v", err)
+func NewHandler(cfg *config.Config, l *logger.Logger) *Handler {
+	h := &Handler{
+		logger: l.Named("events"),
+		events: make(map[reflect.Type][]*types.Event),
 	}
+	h.config.Store(cfg)
+	return h
+}
 
-	h.registerDefaultHandlers()
-	h.logger.Info("Events loaded successfully")
-	return nil
+// SetConfig makes cfg the configuration seen by the default events.
+func (h *Handler) SetConfig(cfg *config.Config) {
+	h.config.Store(cfg)
 }
 
-func (h *Handler) loadEventFiles() error {
-	return filepath.Walk("./events", func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return fmt.Errorf("error accessing path %s: %v", path, err)
-		}
+// LoadEvents builds the dispatch table and attaches it to every given
+// session. Sessions created later are added with Attach.
+func (h *Handler) LoadEvents(sessions ...*discordgo.Session) error {
+	h.logger.Info("Loading events...")
 
-		if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") || strings.HasSuffix(info.Name(), "_test.go") {
-			return nil
-		}
+	events := h.defaultEvents()
+	for _, e := range registry.Events {
+		events = append(events, e)
+	}
 
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("error reading file %s: %v", path, err)
+	table := make(map[reflect.Type][]*types.Event)
+	for _, e := range events {
+		t := e.Handler.Type()
+		if t == nil {
+			return fmt.Errorf("event %s has no handler", e.Name)
 		}
+		table[t] = append(table[t], e)
+	}
+	for _, handlers := range table {
+		sort.SliceStable(handlers, func(i, j int) bool {
+			if handlers[i].Priority != handlers[j].Priority {
+				return handlers[i].Priority > handlers[j].Priority
+			}
+			return handlers[i].Name < handlers[j].Name
+		})
+	}
 
-		if !strings.Contains(string(content), "var") || !strings.Contains(string(content), "Event") {
-			return nil
-		}
+	h.mu.Lock()
+	h.events = table
+	h.mu.Unlock()
 
-		packageName := getPackageName(string(content))
-		if packageName == "" {
-			return nil
-		}
+	for _, s := range sessions {
+		h.Attach(s)
+	}
 
-		h.logger.Info(fmt.Sprintf("Found event in %s", path))
-		return nil
-	})
+	h.logger.Info("Loaded events", "count", len(events))
+	return nil
 }
 
-func (h *Handler) registerDefaultHandlers() {
-	h.session.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
-		h.readyOnce.Do(func() {
-			h.logger.Info(fmt.Sprintf("Logged in as: %v#%v", s.State.User.Username, s.State.User.Discriminator))
-			h.logger.Info(fmt.Sprintf("Bot is in %d guilds", len(s.State.Guilds)))
-
-			err := s.UpdateGameStatus(0, h.config.Discord.Status)
-			if err != nil {
-				h.logger.Error(fmt.Sprintf("Error setting status: %v", err))
+// Attach dispatches the loaded events of session. Each session gets a single
+// catch-all handler, so the handlers of an event run in priority order
+// instead of concurrently, and Once events fire once per session.
+func (h *Handler) Attach(session *discordgo.Session) {
+	var mu sync.Mutex
+	fired := make(map[*types.Event]bool)
+
+	session.AddHandler(func(s *discordgo.Session, evt interface{}) {
+		t := reflect.TypeOf(evt)
+		h.mu.RLock()
+		handlers := h.events[t]
+		h.mu.RUnlock()
+
+		for _, e := range handlers {
+			if !h.available(EventIntents(t) | e.Intents) {
+				continue
 			}
-		})
-	})
-
-	h.session.AddHandler(func(s *discordgo.Session, e interface{}) {
-		if evt, ok := e.(*discordgo.Event); ok {
-			if evt.Type == "ERROR" {
-				h.logger.Error("Discord error event occurred")
+			if e.Once {
+				mu.Lock()
+				done := fired[e]
+				fired[e] = true
+				mu.Unlock()
+				if done {
+					continue
+				}
 			}
+			h.dispatch(s, e, evt)
 		}
 	})
+}
 
-	h.session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
-		if m.Author.ID == s.State.User.ID {
-			return
-		}
-		if h.config.Debug {
-			h.logger.Info(fmt.Sprintf("Message received from %s: %s", m.Author.Username, m.Content))
+func (h *Handler) dispatch(s *discordgo.Session, e *types.Event, evt interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			h.logger.Error("Panic in event", "event", e.Name, "shard", s.ShardID, "panic", r, "stack", string(debug.Stack()))
 		}
-	})
+	}()
+	e.Handler.Handle(s, h.logger.With("event", e.Name, "shard", s.ShardID), evt)
 }
 
-func getPackageName(content string) string {
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(strings.TrimSpace(line), "package") {
-			parts := strings.Fields(line)
-			if len(parts) == 2 {
-				return parts[1]
-			}
-		}
+func (h *Handler) defaultEvents() []*types.Event {
+	// Logging messages only needs their content in debug mode.
+	var debugIntents discordgo.Intent
+	if h.config.Load().Debug {
+		debugIntents = discordgo.IntentMessageContent
+	}
+
+	return []*types.Event{
+		{
+			Name: "status",
+			Once: true,
+			Handler: types.On(func(s *discordgo.Session, r *discordgo.Ready) {
+				h.logger.Info("Logged in", "shard", s.ShardID, "user", s.State.User.Username+"#"+s.State.User.Discriminator)
+				h.logger.Info("Guilds on shard", "shard", s.ShardID, "guilds", len(s.State.Guilds))
+
+				err := s.UpdateGameStatus(0, h.config.Load().Discord.Status)
+				if err != nil {
+					h.logger.Error("Error setting status", "shard", s.ShardID, "error", err)
+				}
+			}),
+		},
+		{
+			Name: "gateway_error",
+			Handler: types.On(func(s *discordgo.Session, evt *discordgo.Event) {
+				if evt.Type == "ERROR" {
+					h.logger.Error("Discord error event occurred", "shard", s.ShardID)
+				}
+			}),
+		},
+		{
+			Name:    "debug_messages",
+			Intents: debugIntents,
+			Handler: types.On(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+				if !h.config.Load().Debug || m.Author == nil || m.Author.ID == s.State.User.ID {
+					return
+				}
+				h.logger.Debug("Message received", "guild", m.GuildID, "channel", m.ChannelID, "author", m.Author.Username, "content", m.Content)
+			}),
+		},
 	}
-	return ""
 }
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PrivilegedIntents must also be enabled for the application in the
+// developer portal, or Discord refuses the connection.
+const PrivilegedIntents = discordgo.IntentGuildMembers | discordgo.IntentGuildPresences | discordgo.IntentMessageContent
+
+// intentNames are the names of the intents in the configuration.
+var intentNames = map[string]discordgo.Intent{
+	"guilds":                        discordgo.IntentGuilds,
+	"guild_members":                 discordgo.IntentGuildMembers,
+	"guild_moderation":              discordgo.IntentGuildModeration,
+	"guild_emojis":                  discordgo.IntentGuildEmojis,
+	"guild_integrations":            discordgo.IntentGuildIntegrations,
+	"guild_webhooks":                discordgo.IntentGuildWebhooks,
+	"guild_invites":                 discordgo.IntentGuildInvites,
+	"guild_voice_states":            discordgo.IntentGuildVoiceStates,
+	"guild_presences":               discordgo.IntentGuildPresences,
+	"guild_messages":                discordgo.IntentGuildMessages,
+	"guild_message_reactions":       discordgo.IntentGuildMessageReactions,
+	"guild_message_typing":          discordgo.IntentGuildMessageTyping,
+	"direct_messages":               discordgo.IntentDirectMessages,
+	"direct_message_reactions":      discordgo.IntentDirectMessageReactions,
+	"direct_message_typing":         discordgo.IntentDirectMessageTyping,
+	"message_content":               discordgo.IntentMessageContent,
+	"guild_scheduled_events":        discordgo.IntentGuildScheduledEvents,
+	"auto_moderation_configuration": discordgo.IntentAutoModerationConfiguration,
+	"auto_moderation_execution":     discordgo.IntentAutoModerationExecution,
+}
+
+// eventIntents maps each gateway event to the intents that deliver it.
+// Events missing here, such as Ready or InteractionCreate, need no intent.
+var eventIntents = map[reflect.Type]discordgo.Intent{
+	typeOf[*discordgo.GuildCreate]():         discordgo.IntentGuilds,
+	typeOf[*discordgo.GuildUpdate]():         discordgo.IntentGuilds,
+	typeOf[*discordgo.GuildDelete]():         discordgo.IntentGuilds,
+	typeOf[*discordgo.GuildRoleCreate]():     discordgo.IntentGuilds,
+	typeOf[*discordgo.GuildRoleUpdate]():     discordgo.IntentGuilds,
+	typeOf[*discordgo.GuildRoleDelete]():     discordgo.IntentGuilds,
+	typeOf[*discordgo.ChannelCreate]():       discordgo.IntentGuilds,
+	typeOf[*discordgo.ChannelUpdate]():       discordgo.IntentGuilds,
+	typeOf[*discordgo.ChannelDelete]():       discordgo.IntentGuilds,
+	typeOf[*discordgo.ChannelPinsUpdate]():   discordgo.IntentGuilds | discordgo.IntentDirectMessages,
+	typeOf[*discordgo.ThreadCreate]():        discordgo.IntentGuilds,
+	typeOf[*discordgo.ThreadUpdate]():        discordgo.IntentGuilds,
+	typeOf[*discordgo.ThreadDelete]():        discordgo.IntentGuilds,
+	typeOf[*discordgo.ThreadListSync]():      discordgo.IntentGuilds,
+	typeOf[*discordgo.ThreadMemberUpdate]():  discordgo.IntentGuilds,
+	typeOf[*discordgo.ThreadMembersUpdate](): discordgo.IntentGuildMembers,
+
+	typeOf[*discordgo.StageInstanceEventCreate](): discordgo.IntentGuilds,
+	typeOf[*discordgo.StageInstanceEventUpdate](): discordgo.IntentGuilds,
+	typeOf[*discordgo.StageInstanceEventDelete](): discordgo.IntentGuilds,
+
+	typeOf[*discordgo.GuildMemberAdd]():    discordgo.IntentGuildMembers,
+	typeOf[*discordgo.GuildMemberUpdate](): discordgo.IntentGuildMembers,
+	typeOf[*discordgo.GuildMemberRemove](): discordgo.IntentGuildMembers,
+
+	typeOf[*discordgo.GuildBanAdd]():              discordgo.IntentGuildModeration,
+	typeOf[*discordgo.GuildBanRemove]():           discordgo.IntentGuildModeration,
+	typeOf[*discordgo.GuildAuditLogEntryCreate](): discordgo.IntentGuildModeration,
+
+	typeOf[*discordgo.GuildEmojisUpdate]():       discordgo.IntentGuildEmojis,
+	typeOf[*discordgo.GuildIntegrationsUpdate](): discordgo.IntentGuildIntegrations,
+	typeOf[*discordgo.WebhooksUpdate]():          discordgo.IntentGuildWebhooks,
+	typeOf[*discordgo.InviteCreate]():            discordgo.IntentGuildInvites,
+	typeOf[*discordgo.InviteDelete]():            discordgo.IntentGuildInvites,
+	typeOf[*discordgo.VoiceStateUpdate]():        discordgo.IntentGuildVoiceStates,
+	typeOf[*discordgo.PresenceUpdate]():          discordgo.IntentGuildPresences,
+
+	typeOf[*discordgo.MessageCreate]():     discordgo.IntentGuildMessages | discordgo.IntentDirectMessages,
+	typeOf[*discordgo.MessageUpdate]():     discordgo.IntentGuildMessages | discordgo.IntentDirectMessages,
+	typeOf[*discordgo.MessageDelete]():     discordgo.IntentGuildMessages | discordgo.IntentDirectMessages,
+	typeOf[*discordgo.MessageDeleteBulk](): discordgo.IntentGuildMessages,
+
+	typeOf[*discordgo.MessageReactionAdd]():       discordgo.IntentGuildMessageReactions | discordgo.IntentDirectMessageReactions,
+	typeOf[*discordgo.MessageReactionRemove]():    discordgo.IntentGuildMessageReactions | discordgo.IntentDirectMessageReactions,
+	typeOf[*discordgo.MessageReactionRemoveAll](): discordgo.IntentGuildMessageReactions | discordgo.IntentDirectMessageReactions,
+	typeOf[*discordgo.TypingStart]():              discordgo.IntentGuildMessageTyping | discordgo.IntentDirectMessageTyping,
+
+	typeOf[*discordgo.GuildScheduledEventCreate]():     discordgo.IntentGuildScheduledEvents,
+	typeOf[*discordgo.GuildScheduledEventUpdate]():     discordgo.IntentGuildScheduledEvents,
+	typeOf[*discordgo.GuildScheduledEventDelete]():     discordgo.IntentGuildScheduledEvents,
+	typeOf[*discordgo.GuildScheduledEventUserAdd]():    discordgo.IntentGuildScheduledEvents,
+	typeOf[*discordgo.GuildScheduledEventUserRemove](): discordgo.IntentGuildScheduledEvents,
+
+	typeOf[*discordgo.AutoModerationRuleCreate]():      discordgo.IntentAutoModerationConfiguration,
+	typeOf[*discordgo.AutoModerationRuleUpdate]():      discordgo.IntentAutoModerationConfiguration,
+	typeOf[*discordgo.AutoModerationRuleDelete]():      discordgo.IntentAutoModerationConfiguration,
+	typeOf[*discordgo.AutoModerationActionExecution](): discordgo.IntentAutoModerationExecution,
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// EventIntents returns the intents that deliver events of type t.
+func EventIntents(t reflect.Type) discordgo.Intent {
+	return eventIntents[t]
+}
+
+// HandlerIntents returns the intents that deliver the events of a handler
+// passed to discordgo's AddHandler, i.e. a func(*discordgo.Session, T).
+func HandlerIntents(handler interface{}) discordgo.Intent {
+	t := reflect.TypeOf(handler)
+	if t == nil || t.Kind() != reflect.Func || t.NumIn() != 2 {
+		return 0
+	}
+	return EventIntents(t.In(1))
+}
+
+// HandlerName returns a short name for a handler function, for logs.
+func HandlerName(handler interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// ParseIntents combines the named intents.
+func ParseIntents(names []string) (discordgo.Intent, error) {
+	var intents discordgo.Intent
+	for _, name := range names {
+		intent, ok := intentNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("unknown intent %q", name)
+		}
+		intents |= intent
+	}
+	return intents, nil
+}
+
+// IntentNames returns the names of the intents set in intents, sorted.
+func IntentNames(intents discordgo.Intent) []string {
+	var names []string
+	for name, intent := range intentNames {
+		if intents&intent != 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DisableIntents marks intents the sessions do not identify with, such as
+// privileged intents not enabled for the application. Events that need one
+// are not dispatched.
+func (h *Handler) DisableIntents(intents discordgo.Intent) {
+	h.disabled.Store(int64(intents))
+}
+
+func (h *Handler) available(need discordgo.Intent) bool {
+	return need&discordgo.Intent(h.disabled.Load()) == 0
+}
+
+// Intents returns the intents needed by the loaded events, keyed by event
+// name.
+func (h *Handler) Intents() map[string]discordgo.Intent {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	intents := make(map[string]discordgo.Intent)
+	for t, handlers := range h.events {
+		for _, e := range handlers {
+			intents["event "+e.Name] |= EventIntents(t) | e.Intents
+		}
+	}
+	return intents
+}
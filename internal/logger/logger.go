@@ -2,79 +2,184 @@
 package logger
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/kevinfinalboss/Void/config"
 )
 
-type Config struct {
-	Level string
-	File  string
+// Logger writes leveled, structured records to stdout and the log file.
+// Messages are constant strings and the details go in key/value pairs, as
+// in log/slog:
+//
+//	l.Error("Failed to upsert guild", "guild", g.ID, "error", err)
+//
+// Named loggers can have their own level, set in logger.levels.
+type Logger struct {
+	slog   *slog.Logger
+	levels *levels
 }
 
-type Logger struct {
-	logger *log.Logger
+// levels holds the default level and the levels of named loggers. It is
+// shared by every logger derived from the same New.
+type levels struct {
+	mu     sync.RWMutex
+	base   slog.Level
+	byName map[string]slog.Level
+}
+
+func (lv *levels) level(name string) slog.Level {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+	if level, ok := lv.byName[name]; ok {
+		return level
+	}
+	return lv.base
+}
+
+func (lv *levels) set(base string, byName map[string]string) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	lv.base = ParseLevel(base)
+	lv.byName = make(map[string]slog.Level, len(byName))
+	for name, level := range byName {
+		lv.byName[name] = ParseLevel(level)
+	}
 }
 
-func New(cfg struct {
-	Level string `yaml:"level"`
-	File  string `yaml:"file"`
-}) *Logger {
-	logConfig := Config{
-		Level: cfg.Level,
-		File:  cfg.File,
+// ParseLevel parses "debug", "info", "warn" or "error". Anything else is
+// info.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
 	}
+}
 
-	if logConfig.File == "" {
-		logConfig.File = "logs/bot.log"
+func New(cfg *config.Config) *Logger {
+	path := cfg.Logger.File
+	if path == "" {
+		path = "logs/bot.log"
 	}
 
-	logConfig.File = strings.ReplaceAll(logConfig.File, "/", string(os.PathSeparator))
+	path = strings.ReplaceAll(path, "/", string(os.PathSeparator))
 
-	if !filepath.IsAbs(logConfig.File) {
+	if !filepath.IsAbs(path) {
 		dir, err := os.Getwd()
 		if err != nil {
 			log.Fatal("Failed to get working directory:", err)
 		}
-		logConfig.File = filepath.Join(dir, logConfig.File)
+		path = filepath.Join(dir, path)
 	}
 
-	logDir := filepath.Dir(logConfig.File)
+	logDir := filepath.Dir(path)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		log.Fatal("Failed to create log directory:", err)
 	}
 
-	file, err := os.OpenFile(logConfig.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
-		log.Fatal(fmt.Sprintf("Failed to open log file %s: %v", logConfig.File, err))
+		log.Fatal(fmt.Sprintf("Failed to open log file %s: %v", path, err))
 	}
 
-	multiWriter := log.New(os.Stdout, "", log.Ldate|log.Ltime)
-	fileLogger := log.New(file, "", log.Ldate|log.Ltime|log.Lshortfile)
+	lv := &levels{}
+	lv.set(cfg.Logger.Level, cfg.Logger.Levels)
+
+	// The handler itself lets everything through; levelHandler filters by
+	// the level of the named logger.
+	out := io.MultiWriter(os.Stdout, file)
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var handler slog.Handler
+	if strings.EqualFold(cfg.Logger.Format, "json") {
+		handler = slog.NewJSONHandler(out, opts)
+	} else {
+		handler = slog.NewTextHandler(out, opts)
+	}
 
-	multiWriter.Printf("Logger initialized. Log file: %s", logConfig.File)
+	l := &Logger{
+		slog:   slog.New(&levelHandler{next: handler, levels: lv}),
+		levels: lv,
+	}
+	l.Info("Logger initialized", "file", path)
+	return l
+}
 
+// Named returns a logger tagged with name, whose level can be set apart in
+// logger.levels.
+func (l *Logger) Named(name string) *Logger {
+	h := l.slog.Handler().(*levelHandler)
+	named := &levelHandler{next: h.next, levels: h.levels, name: name}
 	return &Logger{
-		logger: fileLogger,
+		slog:   slog.New(named).With("logger", name),
+		levels: l.levels,
 	}
 }
 
-func (l *Logger) Info(v ...interface{}) {
-	message := fmt.Sprint(v...)
-	l.logger.Printf("INFO: %s", message)
-	log.Printf("INFO: %s", message)
+// With returns a logger that adds the key/value pairs to every record.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{slog: l.slog.With(args...), levels: l.levels}
+}
+
+// ApplyConfig applies the levels of a reloaded configuration. It is meant
+// to be subscribed to the config.Store.
+func (l *Logger) ApplyConfig(old, cfg *config.Config) {
+	l.levels.set(cfg.Logger.Level, cfg.Logger.Levels)
+}
+
+func (l *Logger) Debug(msg string, args ...any) {
+	l.slog.Debug(msg, args...)
+}
+
+func (l *Logger) Info(msg string, args ...any) {
+	l.slog.Info(msg, args...)
+}
+
+func (l *Logger) Warn(msg string, args ...any) {
+	l.slog.Warn(msg, args...)
+}
+
+func (l *Logger) Error(msg string, args ...any) {
+	l.slog.Error(msg, args...)
+}
+
+// Fatal logs at error level and exits.
+func (l *Logger) Fatal(msg string, args ...any) {
+	l.slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// levelHandler drops the records below the level of its logger name.
+type levelHandler struct {
+	next   slog.Handler
+	levels *levels
+	name   string
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levels.level(h.name)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
 }
 
-func (l *Logger) Error(v ...interface{}) {
-	message := fmt.Sprint(v...)
-	l.logger.Printf("ERROR: %s", message)
-	log.Printf("ERROR: %s", message)
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{next: h.next.WithAttrs(attrs), levels: h.levels, name: h.name}
 }
 
-func (l *Logger) Fatal(v ...interface{}) {
-	message := fmt.Sprint(v...)
-	l.logger.Printf("FATAL: %s", message)
-	log.Fatalf("FATAL: %s", message)
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{next: h.next.WithGroup(name), levels: h.levels, name: h.name}
 }
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// CommandScopes records where the application commands were last
+// registered, so scopes dropped from the configuration can be cleared.
+type CommandScopes struct {
+	ApplicationID string `bson:"_id"`
+	// GuildIDs holds the guilds the commands were registered to, where an
+	// empty ID stands for global registration.
+	GuildIDs []string  `bson:"guild_ids"`
+	SyncedAt time.Time `bson:"synced_at"`
+}
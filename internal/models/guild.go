@@ -24,5 +24,43 @@ type Guild struct {
 }
 
 type GuildSettings struct {
-	AuditLogChannel string `bson:"audit_log_channel"`
+	AuditLogChannel string   `bson:"audit_log_channel"`
+	AuditDisabled   []string `bson:"audit_disabled,omitempty"`
+	Prefix          string   `bson:"prefix,omitempty"`
+	ManagerRoles    []string `bson:"manager_roles,omitempty"`
+}
+
+// Audit event categories. Every category is logged once an audit channel is
+// set, unless it is listed in GuildSettings.AuditDisabled.
+const (
+	AuditMessages      = "messages"
+	AuditMembers       = "members"
+	AuditBans          = "bans"
+	AuditMemberUpdates = "member_updates"
+	AuditChannels      = "channels"
+	AuditVoice         = "voice"
+)
+
+// AuditCategories lists the audit event categories in display order.
+var AuditCategories = []string{
+	AuditMessages,
+	AuditMembers,
+	AuditBans,
+	AuditMemberUpdates,
+	AuditChannels,
+	AuditVoice,
+}
+
+// AuditEnabled reports whether events of the given category should be posted
+// to the audit channel.
+func (s GuildSettings) AuditEnabled(category string) bool {
+	if s.AuditLogChannel == "" {
+		return false
+	}
+	for _, disabled := range s.AuditDisabled {
+		if disabled == category {
+			return false
+		}
+	}
+	return true
 }
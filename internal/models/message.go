@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CachedMessage is a snapshot of a guild message, kept so edits and deletes
+// can be shown with the content Discord no longer sends.
+type CachedMessage struct {
+	MessageID    string             `bson:"message_id"`
+	GuildID      string             `bson:"guild_id"`
+	ChannelID    string             `bson:"channel_id"`
+	AuthorID     string             `bson:"author_id"`
+	AuthorName   string             `bson:"author_name"`
+	AuthorAvatar string             `bson:"author_avatar,omitempty"`
+	AuthorBot    bool               `bson:"author_bot"`
+	Content      string             `bson:"content"`
+	Attachments  []CachedAttachment `bson:"attachments,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	EditedAt     *time.Time         `bson:"edited_at,omitempty"`
+	DeletedAt    *time.Time         `bson:"deleted_at,omitempty"`
+}
+
+type CachedAttachment struct {
+	Filename    string `bson:"filename"`
+	URL         string `bson:"url"`
+	ContentType string `bson:"content_type,omitempty"`
+	Size        int    `bson:"size"`
+}
+
+// Author returns the author as a discordgo user for mentions and avatars.
+func (m *CachedMessage) Author() *discordgo.User {
+	return &discordgo.User{
+		ID:       m.AuthorID,
+		Username: m.AuthorName,
+		Avatar:   m.AuthorAvatar,
+		Bot:      m.AuthorBot,
+	}
+}
+
+// Summary describes the attachment as its file name and size.
+func (a CachedAttachment) Summary() string {
+	switch {
+	case a.Size >= 1<<20:
+		return fmt.Sprintf("%s (%.1f MB)", a.Filename, float64(a.Size)/(1<<20))
+	case a.Size >= 1<<10:
+		return fmt.Sprintf("%s (%.1f KB)", a.Filename, float64(a.Size)/(1<<10))
+	}
+	return fmt.Sprintf("%s (%d B)", a.Filename, a.Size)
+}
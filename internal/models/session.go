@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// GatewaySession is the gateway session of a shard saved on shutdown, so
+// the next process serving the shard can resume it instead of identifying.
+type GatewaySession struct {
+	ShardID    int       `bson:"_id"`
+	ShardCount int       `bson:"shard_count"`
+	SessionID  string    `bson:"session_id"`
+	Sequence   int64     `bson:"sequence"`
+	Intents    int       `bson:"intents"`
+	SavedAt    time.Time `bson:"saved_at"`
+}
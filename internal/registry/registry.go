@@ -6,6 +6,18 @@ import (
 
 var Commands = make(map[string]*types.Command)
 
+var Components = make(map[string]*types.Component)
+
+var Events = make(map[string]*types.Event)
+
 func RegisterCommand(cmd *types.Command) {
 	Commands[cmd.Name] = cmd
 }
+
+func RegisterComponent(c *types.Component) {
+	Components[c.Name] = c
+}
+
+func RegisterEvent(e *types.Event) {
+	Events[e.Name] = e
+}
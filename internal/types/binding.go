@@ -0,0 +1,385 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Commands may declare their options as a struct assigned to Command.Args
+// instead of a list of CommandOption. Each exported field tagged with
+// `option` becomes an option:
+//
+//	type resizeArgs struct {
+//		Image *discordgo.MessageAttachment `option:"imagem" description:"A imagem" required:"true"`
+//		Width int64                        `option:"largura" description:"A largura" min:"1" max:"4000"`
+//	}
+//
+// Supported tags are option, description, required, min and max (the length
+// for strings), choices, written as "Nome=valor;Outro=valor2", channels, a
+// comma separated list of channel types such as "text,voice", and
+// autocomplete. The same struct is then filled by BindOptions when the
+// command runs, which enforces the constraints again for prefix invocations.
+
+var (
+	userType       = reflect.TypeOf(&discordgo.User{})
+	channelType    = reflect.TypeOf(&discordgo.Channel{})
+	roleType       = reflect.TypeOf(&discordgo.Role{})
+	attachmentType = reflect.TypeOf(&discordgo.MessageAttachment{})
+)
+
+var channelTypeNames = map[string]discordgo.ChannelType{
+	"text":           discordgo.ChannelTypeGuildText,
+	"voice":          discordgo.ChannelTypeGuildVoice,
+	"category":       discordgo.ChannelTypeGuildCategory,
+	"news":           discordgo.ChannelTypeGuildNews,
+	"news_thread":    discordgo.ChannelTypeGuildNewsThread,
+	"public_thread":  discordgo.ChannelTypeGuildPublicThread,
+	"private_thread": discordgo.ChannelTypeGuildPrivateThread,
+	"stage":          discordgo.ChannelTypeGuildStageVoice,
+	"forum":          discordgo.ChannelTypeGuildForum,
+}
+
+type argField struct {
+	index  int
+	option *CommandOption
+}
+
+// OptionsFromArgs generates the option metadata declared by the tags of the
+// struct args.
+func OptionsFromArgs(args interface{}) ([]*CommandOption, error) {
+	fields, err := argFields(reflect.TypeOf(args))
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]*CommandOption, 0, len(fields))
+	for _, f := range fields {
+		options = append(options, f.option)
+	}
+	return options, nil
+}
+
+// BindOptions decodes the options of the invoked command into dst, which must
+// be a pointer to an args struct. Validation failures are returned as
+// UserError so they can be shown to the user directly.
+func BindOptions(s *discordgo.Session, i *discordgo.InteractionCreate, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind target must be a pointer to a struct, got %T", dst)
+	}
+
+	fields, err := argFields(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	data := i.ApplicationCommandData()
+	given := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range CommandOptions(i) {
+		given[opt.Name] = opt
+	}
+
+	for _, f := range fields {
+		opt, ok := given[f.option.Name]
+		if !ok {
+			if f.option.Required {
+				return NewUserError(fmt.Sprintf("O argumento `%s` é obrigatório.", f.option.Name), nil)
+			}
+			continue
+		}
+
+		value, err := resolveValue(s, i, data.Resolved, f, opt)
+		if err != nil {
+			return err
+		}
+		if err := f.validate(value); err != nil {
+			return err
+		}
+
+		field := rv.Elem().Field(f.index)
+		v := reflect.ValueOf(value)
+		if !v.Type().AssignableTo(field.Type()) {
+			v = v.Convert(field.Type())
+		}
+		field.Set(v)
+	}
+
+	return nil
+}
+
+func argFields(t reflect.Type) ([]*argField, error) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("args must be a struct, got %v", t)
+	}
+
+	var fields []*argField
+	for idx := 0; idx < t.NumField(); idx++ {
+		sf := t.Field(idx)
+		name, ok := sf.Tag.Lookup("option")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+
+		optType, err := optionType(sf.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", sf.Name, err)
+		}
+
+		f := &argField{
+			index: idx,
+			option: &CommandOption{
+				Name:        name,
+				Description: sf.Tag.Get("description"),
+				Type:        optType,
+				Required:    sf.Tag.Get("required") == "true",
+			},
+		}
+
+		if err := f.applyBounds(sf.Tag.Get("min"), sf.Tag.Get("max")); err != nil {
+			return nil, fmt.Errorf("field %s: %v", sf.Name, err)
+		}
+
+		if raw := sf.Tag.Get("channels"); raw != "" {
+			if optType != discordgo.ApplicationCommandOptionChannel {
+				return nil, fmt.Errorf("field %s: channels is only valid for channel options", sf.Name)
+			}
+			for _, name := range strings.Split(raw, ",") {
+				ct, ok := channelTypeNames[strings.TrimSpace(name)]
+				if !ok {
+					return nil, fmt.Errorf("field %s: unknown channel type %q", sf.Name, name)
+				}
+				f.option.ChannelTypes = append(f.option.ChannelTypes, ct)
+			}
+		}
+
+		f.option.Autocomplete = sf.Tag.Get("autocomplete") == "true"
+
+		if raw := sf.Tag.Get("choices"); raw != "" {
+			for _, pair := range strings.Split(raw, ";") {
+				label, value, ok := strings.Cut(pair, "=")
+				if !ok {
+					return nil, fmt.Errorf("field %s: choice %q must be Nome=valor", sf.Name, pair)
+				}
+				choice, err := choiceValue(optType, value)
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %v", sf.Name, err)
+				}
+				f.option.Choices = append(f.option.Choices, &discordgo.ApplicationCommandOptionChoice{
+					Name:  label,
+					Value: choice,
+				})
+			}
+		}
+
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func optionType(t reflect.Type) (discordgo.ApplicationCommandOptionType, error) {
+	switch t {
+	case userType:
+		return discordgo.ApplicationCommandOptionUser, nil
+	case channelType:
+		return discordgo.ApplicationCommandOptionChannel, nil
+	case roleType:
+		return discordgo.ApplicationCommandOptionRole, nil
+	case attachmentType:
+		return discordgo.ApplicationCommandOptionAttachment, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return discordgo.ApplicationCommandOptionString, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return discordgo.ApplicationCommandOptionInteger, nil
+	case reflect.Float32, reflect.Float64:
+		return discordgo.ApplicationCommandOptionNumber, nil
+	case reflect.Bool:
+		return discordgo.ApplicationCommandOptionBoolean, nil
+	}
+	return 0, fmt.Errorf("unsupported option type %v", t)
+}
+
+// applyBounds stores the min and max tags as value constraints for numeric
+// options and as length constraints for strings.
+func (f *argField) applyBounds(min, max string) error {
+	if min == "" && max == "" {
+		return nil
+	}
+
+	switch f.option.Type {
+	case discordgo.ApplicationCommandOptionInteger, discordgo.ApplicationCommandOptionNumber:
+		if min != "" {
+			v, err := strconv.ParseFloat(min, 64)
+			if err != nil {
+				return fmt.Errorf("invalid min: %v", err)
+			}
+			f.option.MinValue = &v
+		}
+		if max != "" {
+			v, err := strconv.ParseFloat(max, 64)
+			if err != nil {
+				return fmt.Errorf("invalid max: %v", err)
+			}
+			f.option.MaxValue = v
+		}
+	case discordgo.ApplicationCommandOptionString:
+		if min != "" {
+			v, err := strconv.Atoi(min)
+			if err != nil {
+				return fmt.Errorf("invalid min: %v", err)
+			}
+			f.option.MinLength = &v
+		}
+		if max != "" {
+			v, err := strconv.Atoi(max)
+			if err != nil {
+				return fmt.Errorf("invalid max: %v", err)
+			}
+			f.option.MaxLength = v
+		}
+	default:
+		return fmt.Errorf("min and max are not supported for %v options", f.option.Type)
+	}
+	return nil
+}
+
+func choiceValue(t discordgo.ApplicationCommandOptionType, raw string) (interface{}, error) {
+	switch t {
+	case discordgo.ApplicationCommandOptionString:
+		return raw, nil
+	case discordgo.ApplicationCommandOptionInteger:
+		return strconv.ParseInt(raw, 10, 64)
+	case discordgo.ApplicationCommandOptionNumber:
+		return strconv.ParseFloat(raw, 64)
+	}
+	return nil, fmt.Errorf("choices are not supported for %v options", t)
+}
+
+func resolveValue(s *discordgo.Session, i *discordgo.InteractionCreate, resolved *discordgo.ApplicationCommandInteractionDataResolved, f *argField, opt *discordgo.ApplicationCommandInteractionDataOption) (interface{}, error) {
+	if resolved == nil {
+		resolved = &discordgo.ApplicationCommandInteractionDataResolved{}
+	}
+	id, _ := opt.Value.(string)
+
+	switch f.option.Type {
+	case discordgo.ApplicationCommandOptionString:
+		return opt.StringValue(), nil
+	case discordgo.ApplicationCommandOptionInteger:
+		return opt.IntValue(), nil
+	case discordgo.ApplicationCommandOptionNumber:
+		return opt.FloatValue(), nil
+	case discordgo.ApplicationCommandOptionBoolean:
+		return opt.BoolValue(), nil
+	case discordgo.ApplicationCommandOptionUser:
+		if u := resolved.Users[id]; u != nil {
+			return u, nil
+		}
+		if u, err := s.User(id); err == nil {
+			return u, nil
+		}
+		return nil, NewUserError(fmt.Sprintf("Usuário inválido em `%s`.", f.option.Name), nil)
+	case discordgo.ApplicationCommandOptionChannel:
+		if c := resolved.Channels[id]; c != nil {
+			return c, nil
+		}
+		if c, err := s.State.Channel(id); err == nil {
+			return c, nil
+		}
+		if c, err := s.Channel(id); err == nil {
+			return c, nil
+		}
+		return nil, NewUserError(fmt.Sprintf("Canal inválido em `%s`.", f.option.Name), nil)
+	case discordgo.ApplicationCommandOptionRole:
+		if r := resolved.Roles[id]; r != nil {
+			return r, nil
+		}
+		if r, err := s.State.Role(i.GuildID, id); err == nil {
+			return r, nil
+		}
+		return nil, NewUserError(fmt.Sprintf("Cargo inválido em `%s`.", f.option.Name), nil)
+	case discordgo.ApplicationCommandOptionAttachment:
+		if a := resolved.Attachments[id]; a != nil {
+			return a, nil
+		}
+		return nil, NewUserError(fmt.Sprintf("Falha ao resolver o anexo `%s`.", f.option.Name), nil)
+	}
+	return nil, fmt.Errorf("unsupported option type %v", f.option.Type)
+}
+
+func (f *argField) validate(value interface{}) error {
+	opt := f.option
+	name := opt.Name
+
+	if len(opt.Choices) > 0 {
+		valid := false
+		labels := make([]string, 0, len(opt.Choices))
+		for _, c := range opt.Choices {
+			labels = append(labels, fmt.Sprint(c.Value))
+			if fmt.Sprint(c.Value) == fmt.Sprint(value) {
+				valid = true
+			}
+		}
+		if !valid {
+			return NewUserError(fmt.Sprintf("`%s` deve ser um dos valores: %s.", name, strings.Join(labels, ", ")), nil)
+		}
+	}
+
+	var (
+		n        float64
+		min, max *float64
+		unit     string
+	)
+	switch v := value.(type) {
+	case int64:
+		n = float64(v)
+		min, max = valueBounds(opt)
+	case float64:
+		n = v
+		min, max = valueBounds(opt)
+	case string:
+		n, unit = float64(len([]rune(v))), " caracteres"
+		if opt.MinLength != nil {
+			l := float64(*opt.MinLength)
+			min = &l
+		}
+		if opt.MaxLength > 0 {
+			l := float64(opt.MaxLength)
+			max = &l
+		}
+	case *discordgo.Channel:
+		if len(opt.ChannelTypes) == 0 {
+			return nil
+		}
+		for _, ct := range opt.ChannelTypes {
+			if v.Type == ct {
+				return nil
+			}
+		}
+		return NewUserError(fmt.Sprintf("O canal informado em `%s` não é de um tipo aceito.", name), nil)
+	default:
+		return nil
+	}
+
+	switch {
+	case min != nil && max != nil && (n < *min || n > *max):
+		return NewUserError(fmt.Sprintf("`%s` deve estar entre %v e %v%s.", name, *min, *max, unit), nil)
+	case min != nil && n < *min:
+		return NewUserError(fmt.Sprintf("`%s` deve ser no mínimo %v%s.", name, *min, unit), nil)
+	case max != nil && n > *max:
+		return NewUserError(fmt.Sprintf("`%s` deve ser no máximo %v%s.", name, *max, unit), nil)
+	}
+	return nil
+}
+
+func valueBounds(opt *CommandOption) (min, max *float64) {
+	if opt.MaxValue != 0 {
+		max = &opt.MaxValue
+	}
+	return opt.MinValue, max
+}
@@ -0,0 +1,191 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type bindArgs struct {
+	Name    string             `option:"nome" description:"O nome" required:"true" min:"2" max:"5"`
+	Count   int64              `option:"quantidade" description:"A quantidade" min:"1" max:"10"`
+	Mode    string             `option:"modo" description:"O modo" choices:"Rápido=fast;Lento=slow"`
+	Silent  bool               `option:"silencioso" description:"Sem aviso"`
+	Channel *discordgo.Channel `option:"canal" description:"O canal" channels:"text,news"`
+	Search  string             `option:"busca" description:"A busca" autocomplete:"true"`
+	Plain   int
+}
+
+func TestOptionsFromArgs(t *testing.T) {
+	options, err := OptionsFromArgs(bindArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{"nome", "quantidade", "modo", "silencioso", "canal", "busca"}
+	if len(options) != len(names) {
+		t.Fatalf("got %d options, want %d", len(options), len(names))
+	}
+	for i, name := range names {
+		if options[i].Name != name {
+			t.Errorf("option %d is %q, want %q", i, options[i].Name, name)
+		}
+	}
+
+	name, count, mode, channel, search := options[0], options[1], options[2], options[4], options[5]
+	if name.Type != discordgo.ApplicationCommandOptionString || !name.Required || name.Description != "O nome" {
+		t.Errorf("nome = %+v", name)
+	}
+	if name.MinLength == nil || *name.MinLength != 2 || name.MaxLength != 5 {
+		t.Errorf("nome length bounds = %v, %d", name.MinLength, name.MaxLength)
+	}
+	if count.Type != discordgo.ApplicationCommandOptionInteger || count.MinValue == nil || *count.MinValue != 1 || count.MaxValue != 10 {
+		t.Errorf("quantidade = %+v", count)
+	}
+	if len(mode.Choices) != 2 || mode.Choices[0].Name != "Rápido" || mode.Choices[0].Value != "fast" {
+		t.Errorf("modo choices = %+v", mode.Choices)
+	}
+	if options[3].Type != discordgo.ApplicationCommandOptionBoolean {
+		t.Errorf("silencioso type = %v", options[3].Type)
+	}
+	want := []discordgo.ChannelType{discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews}
+	if channel.Type != discordgo.ApplicationCommandOptionChannel || len(channel.ChannelTypes) != 2 ||
+		channel.ChannelTypes[0] != want[0] || channel.ChannelTypes[1] != want[1] {
+		t.Errorf("canal = %+v", channel)
+	}
+	if !search.Autocomplete {
+		t.Error("busca is not autocompleted")
+	}
+}
+
+func TestOptionsFromArgsErrors(t *testing.T) {
+	tests := map[string]interface{}{
+		"not a struct": "args",
+		"unsupported type": struct {
+			F []string `option:"f"`
+		}{},
+		"invalid min": struct {
+			F int64 `option:"f" min:"um"`
+		}{},
+		"bounds on a bool": struct {
+			F bool `option:"f" max:"1"`
+		}{},
+		"channels on string": struct {
+			F string `option:"f" channels:"text"`
+		}{},
+		"unknown channel": struct {
+			F *discordgo.Channel `option:"f" channels:"dm"`
+		}{},
+		"malformed choice": struct {
+			F string `option:"f" choices:"a"`
+		}{},
+		"invalid choice": struct {
+			F int64 `option:"f" choices:"Um=um"`
+		}{},
+		"choices on a bool": struct {
+			F bool `option:"f" choices:"Sim=true"`
+		}{},
+	}
+	for name, args := range tests {
+		if _, err := OptionsFromArgs(args); err == nil {
+			t.Errorf("%s: OptionsFromArgs() = nil, want an error", name)
+		}
+	}
+}
+
+func commandInteraction(options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: "test",
+			// Bound options are read from the innermost subcommand.
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+				Name:    "sub",
+				Type:    discordgo.ApplicationCommandOptionSubCommand,
+				Options: options,
+			}},
+			Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+				Channels: map[string]*discordgo.Channel{
+					"10": {ID: "10", Type: discordgo.ChannelTypeGuildText},
+					"20": {ID: "20", Type: discordgo.ChannelTypeGuildVoice},
+				},
+			},
+		},
+	}}
+}
+
+func option(name string, t discordgo.ApplicationCommandOptionType, value interface{}) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: t, Value: value}
+}
+
+func TestBindOptions(t *testing.T) {
+	i := commandInteraction(
+		option("nome", discordgo.ApplicationCommandOptionString, "void"),
+		// Integers arrive as JSON numbers.
+		option("quantidade", discordgo.ApplicationCommandOptionInteger, float64(3)),
+		option("modo", discordgo.ApplicationCommandOptionString, "slow"),
+		option("silencioso", discordgo.ApplicationCommandOptionBoolean, true),
+		option("canal", discordgo.ApplicationCommandOptionChannel, "10"),
+	)
+
+	var args bindArgs
+	if err := BindOptions(nil, i, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Name != "void" || args.Count != 3 || args.Mode != "slow" || !args.Silent {
+		t.Errorf("bound %+v", args)
+	}
+	if args.Channel == nil || args.Channel.ID != "10" {
+		t.Errorf("bound channel %+v", args.Channel)
+	}
+	if args.Search != "" {
+		t.Errorf("missing optional option bound to %q", args.Search)
+	}
+}
+
+func TestBindOptionsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []*discordgo.ApplicationCommandInteractionDataOption
+	}{
+		{"missing required", nil},
+		{"string too short", []*discordgo.ApplicationCommandInteractionDataOption{
+			option("nome", discordgo.ApplicationCommandOptionString, "v"),
+		}},
+		{"string too long", []*discordgo.ApplicationCommandInteractionDataOption{
+			option("nome", discordgo.ApplicationCommandOptionString, "voidvoid"),
+		}},
+		{"integer below min", []*discordgo.ApplicationCommandInteractionDataOption{
+			option("nome", discordgo.ApplicationCommandOptionString, "void"),
+			option("quantidade", discordgo.ApplicationCommandOptionInteger, float64(0)),
+		}},
+		{"integer above max", []*discordgo.ApplicationCommandInteractionDataOption{
+			option("nome", discordgo.ApplicationCommandOptionString, "void"),
+			option("quantidade", discordgo.ApplicationCommandOptionInteger, float64(11)),
+		}},
+		{"unknown choice", []*discordgo.ApplicationCommandInteractionDataOption{
+			option("nome", discordgo.ApplicationCommandOptionString, "void"),
+			option("modo", discordgo.ApplicationCommandOptionString, "medium"),
+		}},
+		{"wrong channel type", []*discordgo.ApplicationCommandInteractionDataOption{
+			option("nome", discordgo.ApplicationCommandOptionString, "void"),
+			option("canal", discordgo.ApplicationCommandOptionChannel, "20"),
+		}},
+	}
+
+	for _, tt := range tests {
+		var args bindArgs
+		err := BindOptions(nil, commandInteraction(tt.options...), &args)
+		var userErr *UserError
+		if !errors.As(err, &userErr) {
+			t.Errorf("%s: BindOptions() = %v, want a UserError", tt.name, err)
+		}
+	}
+}
+
+func TestBindOptionsTarget(t *testing.T) {
+	if err := BindOptions(nil, commandInteraction(), bindArgs{}); err == nil {
+		t.Error("BindOptions() accepted a struct that is not a pointer")
+	}
+}
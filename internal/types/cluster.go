@@ -0,0 +1,16 @@
+package types
+
+import "context"
+
+// ClusterStats sums the state of every process running the bot.
+type ClusterStats struct {
+	Processes int
+	Shards    int
+	Guilds    int
+}
+
+// Cluster answers queries spanning every process of the bot. Outside
+// cluster mode it only covers the local process.
+type Cluster interface {
+	Stats(ctx context.Context) (ClusterStats, error)
+}
@@ -1,30 +1,73 @@
 package types
 
 import (
+	"context"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
-	"github.com/kevinfinalboss/Void/config"
 )
 
+// CommandOption describes an option of a command. The constraints are sent to
+// Discord with the command schema so invalid input is rejected by the client
+// before the command runs. As in discordgo, a zero MaxValue or MaxLength means
+// no upper bound.
 type CommandOption struct {
-	Name        string
-	Description string
-	Type        discordgo.ApplicationCommandOptionType
-	Required    bool
-	Choices     []*discordgo.ApplicationCommandOptionChoice
+	Name         string
+	Description  string
+	Type         discordgo.ApplicationCommandOptionType
+	Required     bool
+	Choices      []*discordgo.ApplicationCommandOptionChoice
+	MinValue     *float64
+	MaxValue     float64
+	MinLength    *int
+	MaxLength    int
+	ChannelTypes []discordgo.ChannelType
+	Autocomplete bool
 }
 
+// RunFunc is the signature shared by command handlers and the handlers
+// produced by middleware. ctx is cancelled once the command's Timeout
+// elapses.
+type RunFunc func(ctx context.Context, inv *Invocation) error
+
+// UserRunFunc handles a user context-menu command. member is nil when the
+// command is used outside a guild.
+type UserRunFunc func(ctx context.Context, inv *Invocation, target *discordgo.User, member *discordgo.Member) error
+
+// MessageRunFunc handles a message context-menu command.
+type MessageRunFunc func(ctx context.Context, inv *Invocation, target *discordgo.Message) error
+
+// AutoCompleteFunc returns the suggestions for the focused option of an
+// autocomplete interaction.
+type AutoCompleteFunc func(ctx context.Context, inv *Invocation) ([]*discordgo.ApplicationCommandOptionChoice, error)
+
+// Middleware wraps the execution of cmd. Implementations call next to
+// continue the chain or return early to stop it.
+type Middleware func(cmd *Command, next RunFunc) RunFunc
+
 type Command struct {
-	Name         string
-	Description  string
-	Category     string
-	Cooldown     time.Duration
-	AllowPrefix  bool
+	Name        string
+	Description string
+	Category    string
+	Cooldown    time.Duration
+	AllowPrefix bool
+	// Intents lists the gateway intents the command relies on, e.g.
+	// IntentGuildMembers to read members from the state.
+	Intents discordgo.Intent
+	// Requires lists the optional config features the command needs, e.g.
+	// config.FeatureCloudinary. It is not registered while one is disabled.
+	Requires     []string
 	DevOnly      bool
 	AdminOnly    bool
+	Defer        bool
+	Timeout      time.Duration
 	CommandType  discordgo.ApplicationCommandType
 	Options      []*CommandOption
-	AutoComplete func(s *discordgo.Session, i *discordgo.InteractionCreate) ([]*discordgo.ApplicationCommandOptionChoice, error)
-	Run          func(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config) error
+	Args         interface{}
+	Subcommands  []*Command
+	Middlewares  []Middleware
+	AutoComplete AutoCompleteFunc
+	Run          RunFunc
+	RunUser      UserRunFunc
+	RunMessage   MessageRunFunc
 }
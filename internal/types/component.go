@@ -0,0 +1,170 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ComponentFunc handles a click, select or modal submit routed to a
+// Component. id holds the state encoded in the CustomID.
+type ComponentFunc func(ctx context.Context, inv *Invocation, id *ComponentID) error
+
+// Component handles the message components and modals whose CustomID was
+// built with its Name.
+type Component struct {
+	Name string
+	Run  ComponentFunc
+}
+
+const (
+	componentOwnerKey   = "u"
+	componentExpiresKey = "x"
+)
+
+// ComponentID is the state carried by the CustomID of a component, encoded
+// as name:key:value:key:value, e.g. "help:page:3:u:123:x:sd2k1c". Discord
+// limits CustomIDs to 100 characters, so keep the state small and the name
+// free of colons.
+type ComponentID struct {
+	Name string
+	// Owner, when set, is the only user allowed to use the component.
+	Owner string
+	// Expires, when set, is when the router starts rejecting the component.
+	Expires time.Time
+
+	keys   []string
+	values map[string]string
+}
+
+// NewComponentID returns an ID routed to the component registered as name,
+// restricted to owner and valid for ttl. Either may be left empty.
+func NewComponentID(name, owner string, ttl time.Duration) *ComponentID {
+	id := &ComponentID{Name: name, Owner: owner, values: make(map[string]string)}
+	if ttl > 0 {
+		id.Expires = time.Now().Add(ttl)
+	}
+	return id
+}
+
+// With sets key to value and returns id for chaining. The keys "u" and "x"
+// are reserved for the owner and the expiry; using them panics, as the value
+// would be read back as the owner or expiry of the component.
+func (id *ComponentID) With(key, value string) *ComponentID {
+	if key == componentOwnerKey || key == componentExpiresKey {
+		panic(fmt.Sprintf("component id key %q is reserved", key))
+	}
+	if _, ok := id.values[key]; !ok {
+		id.keys = append(id.keys, key)
+	}
+	id.values[key] = value
+	return id
+}
+
+func (id *ComponentID) Get(key string) string {
+	return id.values[key]
+}
+
+func (id *ComponentID) Int(key string) (int, error) {
+	return strconv.Atoi(id.values[key])
+}
+
+// Expired reports whether the component is past its expiry.
+func (id *ComponentID) Expired() bool {
+	return !id.Expires.IsZero() && time.Now().After(id.Expires)
+}
+
+func (id *ComponentID) String() string {
+	parts := []string{id.Name}
+	for _, key := range id.keys {
+		parts = append(parts, key, url.QueryEscape(id.values[key]))
+	}
+	if id.Owner != "" {
+		parts = append(parts, componentOwnerKey, id.Owner)
+	}
+	if !id.Expires.IsZero() {
+		parts = append(parts, componentExpiresKey, strconv.FormatInt(id.Expires.Unix(), 36))
+	}
+	return strings.Join(parts, ":")
+}
+
+// ParseComponentID decodes a CustomID built with ComponentID.
+func ParseComponentID(customID string) (*ComponentID, error) {
+	parts := strings.Split(customID, ":")
+	if parts[0] == "" || len(parts)%2 == 0 {
+		return nil, fmt.Errorf("malformed component id %q", customID)
+	}
+
+	id := &ComponentID{Name: parts[0], values: make(map[string]string)}
+	for idx := 1; idx < len(parts); idx += 2 {
+		key, raw := parts[idx], parts[idx+1]
+		switch key {
+		case componentOwnerKey:
+			id.Owner = raw
+		case componentExpiresKey:
+			unix, err := strconv.ParseInt(raw, 36, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed expiry in component id %q", customID)
+			}
+			id.Expires = time.Unix(unix, 0)
+		default:
+			value, err := url.QueryUnescape(raw)
+			if err != nil {
+				return nil, fmt.Errorf("malformed value in component id %q", customID)
+			}
+			id.With(key, value)
+		}
+	}
+	return id, nil
+}
+
+// CustomID returns the CustomID of the component or modal interaction.
+func CustomID(i *discordgo.InteractionCreate) string {
+	switch i.Type {
+	case discordgo.InteractionMessageComponent:
+		return i.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		return i.ModalSubmitData().CustomID
+	}
+	return ""
+}
+
+// ModalValue returns the value of the text input with the given CustomID in
+// a modal submit.
+func ModalValue(i *discordgo.InteractionCreate, customID string) string {
+	for _, row := range i.ModalSubmitData().Components {
+		actions, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, c := range actions.Components {
+			if input, ok := c.(*discordgo.TextInput); ok && input.CustomID == customID {
+				return input.Value
+			}
+		}
+	}
+	return ""
+}
+
+// UpdateMessage answers a component interaction by editing the message the
+// component is attached to.
+func (inv *Invocation) UpdateMessage(data *discordgo.InteractionResponseData) error {
+	return inv.Session.InteractionRespond(inv.Interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: data,
+	})
+}
+
+// OpenModal answers the interaction with a modal. Modals cannot be opened
+// from prefix invocations or in response to a modal submit.
+func (inv *Invocation) OpenModal(data *discordgo.InteractionResponseData) error {
+	return inv.Session.InteractionRespond(inv.Interaction.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: data,
+	})
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComponentIDRoundTrip(t *testing.T) {
+	id := NewComponentID("help", "123", time.Hour).
+		With("page", "3").
+		With("query", "a:b c").
+		With("page", "4")
+
+	encoded := id.String()
+	parsed, err := ParseComponentID(encoded)
+	if err != nil {
+		t.Fatalf("ParseComponentID(%q) = %v", encoded, err)
+	}
+
+	if parsed.Name != "help" || parsed.Owner != "123" {
+		t.Errorf("parsed name %q and owner %q", parsed.Name, parsed.Owner)
+	}
+	if parsed.Get("page") != "4" || parsed.Get("query") != "a:b c" {
+		t.Errorf("parsed values page=%q query=%q", parsed.Get("page"), parsed.Get("query"))
+	}
+	if n, err := parsed.Int("page"); err != nil || n != 4 {
+		t.Errorf("Int(page) = %d, %v", n, err)
+	}
+	if parsed.Expires.Unix() != id.Expires.Unix() || parsed.Expired() {
+		t.Errorf("parsed expiry %v, want %v", parsed.Expires, id.Expires)
+	}
+	if parsed.String() != encoded {
+		t.Errorf("re-encoded %q, want %q", parsed.String(), encoded)
+	}
+}
+
+func TestComponentIDWithoutOwnerOrExpiry(t *testing.T) {
+	id := NewComponentID("vote", "", 0).With("option", "1")
+	if got := id.String(); got != "vote:option:1" {
+		t.Errorf("String() = %q", got)
+	}
+	if id.Expired() {
+		t.Error("component without expiry reported as expired")
+	}
+}
+
+func TestComponentIDExpired(t *testing.T) {
+	id := NewComponentID("help", "", time.Hour)
+	id.Expires = time.Now().Add(-time.Second)
+
+	parsed, err := ParseComponentID(id.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Expired() {
+		t.Error("past expiry not reported as expired")
+	}
+}
+
+func TestParseComponentIDErrors(t *testing.T) {
+	for _, customID := range []string{
+		"",
+		":page:1",
+		"help:page",
+		"help:x:not-base36!",
+		"help:q:%zz",
+	} {
+		if _, err := ParseComponentID(customID); err == nil {
+			t.Errorf("ParseComponentID(%q) = nil, want an error", customID)
+		}
+	}
+}
+
+func TestComponentIDReservedKeys(t *testing.T) {
+	for _, key := range []string{componentOwnerKey, componentExpiresKey} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("With(%q) did not panic", key)
+				}
+			}()
+			NewComponentID("help", "", 0).With(key, "1")
+		}()
+	}
+}
@@ -0,0 +1,23 @@
+package types
+
+// UserError is returned by commands when the failure should be shown to
+// the user as is. Err keeps the underlying cause for logging.
+type UserError struct {
+	Message string
+	Err     error
+}
+
+func NewUserError(message string, err error) error {
+	return &UserError{Message: message, Err: err}
+}
+
+func (e *UserError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *UserError) Unwrap() error {
+	return e.Err
+}
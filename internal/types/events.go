@@ -1,6 +1,59 @@
 package types
 
+import (
+	"reflect"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/internal/logger"
+)
+
+// Event is a gateway event handler registered with the event registry.
+// Handlers of the same event type run one after another, highest Priority
+// first, so a handler can rely on the ones ranked above it having finished.
 type Event struct {
-	Name    string
-	Handler interface{}
+	Name     string
+	Priority int
+	// Once runs the handler only for the first matching event of each
+	// session, e.g. for Ready, which is sent again on every reconnect.
+	Once bool
+	// Intents lists the intents the handler needs besides the ones that
+	// deliver its event, e.g. IntentMessageContent to read messages.
+	Intents discordgo.Intent
+	Handler EventHandler
+}
+
+// EventHandler is a typed handler built with On or OnLogged.
+type EventHandler struct {
+	eventType reflect.Type
+	handle    func(s *discordgo.Session, l *logger.Logger, evt interface{})
+}
+
+// On wraps fn as the handler of the discordgo event type T, e.g.
+// On(func(s *discordgo.Session, r *discordgo.Ready) { ... }).
+func On[T any](fn func(s *discordgo.Session, evt T)) EventHandler {
+	return OnLogged(func(s *discordgo.Session, l *logger.Logger, evt T) {
+		fn(s, evt)
+	})
+}
+
+// OnLogged is On for handlers that log. l is tagged with the event name and
+// the shard.
+func OnLogged[T any](fn func(s *discordgo.Session, l *logger.Logger, evt T)) EventHandler {
+	return EventHandler{
+		eventType: reflect.TypeOf((*T)(nil)).Elem(),
+		handle: func(s *discordgo.Session, l *logger.Logger, evt interface{}) {
+			fn(s, l, evt.(T))
+		},
+	}
+}
+
+// Type returns the event type the handler accepts, or nil if it was not
+// built with On.
+func (h EventHandler) Type() reflect.Type {
+	return h.eventType
+}
+
+// Handle calls the handler with evt, which must be of the handler's Type.
+func (h EventHandler) Handle(s *discordgo.Session, l *logger.Logger, evt interface{}) {
+	h.handle(s, l, evt)
 }
@@ -0,0 +1,45 @@
+package types
+
+import "github.com/bwmarrin/discordgo"
+
+// InteractionUser returns the user that triggered the interaction, both in
+// guilds and in direct messages.
+func InteractionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+func InteractionUserID(i *discordgo.InteractionCreate) string {
+	if u := InteractionUser(i); u != nil {
+		return u.ID
+	}
+	return ""
+}
+
+// CommandOptions returns the options of the invoked leaf command, skipping
+// any subcommand group and subcommand wrappers.
+func CommandOptions(i *discordgo.InteractionCreate) []*discordgo.ApplicationCommandInteractionDataOption {
+	options := i.ApplicationCommandData().Options
+	for len(options) > 0 {
+		opt := options[0]
+		if opt.Type != discordgo.ApplicationCommandOptionSubCommand &&
+			opt.Type != discordgo.ApplicationCommandOptionSubCommandGroup {
+			break
+		}
+		options = opt.Options
+	}
+	return options
+}
+
+// FocusedOption returns the option being typed in an autocomplete
+// interaction, or nil if there is none.
+func FocusedOption(i *discordgo.InteractionCreate) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, opt := range CommandOptions(i) {
+		if opt.Focused {
+			return opt
+		}
+	}
+	return nil
+}
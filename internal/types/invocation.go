@@ -0,0 +1,121 @@
+package types
+
+import (
+	"reflect"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+	"github.com/kevinfinalboss/Void/internal/cache"
+	"github.com/kevinfinalboss/Void/internal/database"
+	"github.com/kevinfinalboss/Void/internal/logger"
+	"github.com/kevinfinalboss/Void/internal/models"
+)
+
+// Invocation carries everything a command needs to handle one execution.
+// The context passed alongside it is cancelled when the command times out,
+// so long downloads and uploads should be bound to it.
+type Invocation struct {
+	Session     *discordgo.Session
+	Interaction *discordgo.InteractionCreate
+	Config      *config.Config
+	Logger      *logger.Logger
+	DB          *database.MongoDB
+	Messages    *cache.MessageCache
+	Cluster     Cluster
+
+	// Responder delivers the replies, either through the interaction
+	// webhook or as channel messages for prefix invocations.
+	Responder Responder
+
+	// User is the invoking user. Member is nil outside guilds and Guild is
+	// nil when the guild is not in the state cache.
+	User    *discordgo.User
+	Member  *discordgo.Member
+	GuildID string
+	Guild   *discordgo.Guild
+
+	// Args holds the options of a command declaring Args, bound and
+	// validated once before it runs, as a pointer to a value of that type.
+	Args interface{}
+}
+
+// NewInvocation resolves the user and guild of i. r may be nil, in which
+// case replies go through the interaction webhook.
+func NewInvocation(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *config.Config, l *logger.Logger, db *database.MongoDB, r Responder) *Invocation {
+	if r == nil {
+		r = NewInteractionResponder(s, i.Interaction)
+	}
+
+	inv := &Invocation{
+		Session:     s,
+		Interaction: i,
+		Config:      cfg,
+		Logger:      l,
+		DB:          db,
+		Responder:   r,
+		User:        InteractionUser(i),
+		Member:      i.Member,
+		GuildID:     i.GuildID,
+	}
+	if i.GuildID != "" && s.State != nil {
+		if g, err := s.State.Guild(i.GuildID); err == nil {
+			inv.Guild = g
+		}
+	}
+	return inv
+}
+
+// Bind decodes the options of the invocation into dst. See BindOptions.
+// Options already bound into Args are copied rather than decoded again.
+func (inv *Invocation) Bind(dst interface{}) error {
+	if inv.Args != nil && reflect.TypeOf(inv.Args) == reflect.TypeOf(dst) {
+		reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(inv.Args).Elem())
+		return nil
+	}
+	return BindOptions(inv.Session, inv.Interaction, dst)
+}
+
+// UserID returns the ID of the invoking user, or an empty string if the
+// interaction carries no user.
+func (inv *Invocation) UserID() string {
+	if inv.User == nil {
+		return ""
+	}
+	return inv.User.ID
+}
+
+// Settings returns the settings of the invocation's guild, or zero settings
+// outside guilds.
+func (inv *Invocation) Settings() (models.GuildSettings, error) {
+	if inv.GuildID == "" || inv.DB == nil {
+		return models.GuildSettings{}, nil
+	}
+	return inv.DB.GetGuildSettings(inv.GuildID)
+}
+
+// Prefix returns the prefix for text commands in the invocation's guild.
+func (inv *Invocation) Prefix() string {
+	settings, err := inv.Settings()
+	if err != nil || settings.Prefix == "" {
+		return inv.Config.Discord.Prefix
+	}
+	return settings.Prefix
+}
+
+// Shard returns the ID of the shard the invocation arrived on and the total
+// number of shards.
+func (inv *Invocation) Shard() (id, count int) {
+	count = inv.Session.ShardCount
+	if count < 1 {
+		count = 1
+	}
+	return inv.Session.ShardID, count
+}
+
+// ShardGuilds returns how many guilds the invocation's shard serves. Each
+// shard's state only holds its own guilds.
+func (inv *Invocation) ShardGuilds() int {
+	inv.Session.State.RLock()
+	defer inv.Session.State.RUnlock()
+	return len(inv.Session.State.Guilds)
+}
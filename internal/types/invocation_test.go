@@ -0,0 +1,39 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestBindReusesBoundArgs(t *testing.T) {
+	// With no interaction, binding again would panic, so this also checks
+	// the options are not decoded twice.
+	inv := &Invocation{Args: &bindArgs{Name: "void", Count: 3}}
+
+	var args bindArgs
+	if err := inv.Bind(&args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Name != "void" || args.Count != 3 {
+		t.Errorf("bound %+v", args)
+	}
+}
+
+func TestBindDecodesOtherTypes(t *testing.T) {
+	type otherArgs struct {
+		Name string `option:"nome"`
+	}
+	inv := &Invocation{
+		Interaction: commandInteraction(option("nome", discordgo.ApplicationCommandOptionString, "outro")),
+		Args:        &bindArgs{Name: "void"},
+	}
+
+	var args otherArgs
+	if err := inv.Bind(&args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Name != "outro" {
+		t.Errorf("bound %+v", args)
+	}
+}
@@ -0,0 +1,65 @@
+package types
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/kevinfinalboss/Void/config"
+)
+
+// AdminPermissions are the permissions required by AdminOnly commands.
+const AdminPermissions = discordgo.PermissionAdministrator | discordgo.PermissionManageServer
+
+func IsDeveloper(cfg *config.Config, userID string) bool {
+	for _, id := range cfg.Discord.Devs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAdminPermission relies on the permissions Discord resolves for the
+// member in the interaction payload, which already account for roles and
+// channel overwrites.
+func HasAdminPermission(i *discordgo.InteractionCreate) bool {
+	if i.GuildID == "" || i.Member == nil {
+		return false
+	}
+	return i.Member.Permissions&AdminPermissions != 0
+}
+
+// IsAdmin reports whether the invoking member may use AdminOnly commands,
+// either through their permissions or through one of the manager roles
+// configured for the guild.
+func (inv *Invocation) IsAdmin() bool {
+	if HasAdminPermission(inv.Interaction) {
+		return true
+	}
+	if inv.Member == nil || len(inv.Member.Roles) == 0 {
+		return false
+	}
+
+	settings, err := inv.Settings()
+	if err != nil {
+		return false
+	}
+	for _, role := range inv.Member.Roles {
+		for _, manager := range settings.ManagerRoles {
+			if role == manager {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanUse reports whether the invoking user passes the DevOnly and AdminOnly
+// restrictions of cmd.
+func (inv *Invocation) CanUse(cmd *Command) bool {
+	if cmd.DevOnly && !IsDeveloper(inv.Config, inv.UserID()) {
+		return false
+	}
+	if cmd.AdminOnly && !inv.IsAdmin() {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package types
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// Responder sends the replies of a command invocation. Slash and
+// context-menu commands answer through the interaction webhook, while prefix
+// commands answer with regular channel messages.
+type Responder interface {
+	Defer(ephemeral bool) error
+	Respond(data *discordgo.InteractionResponseData) error
+	Edit(edit *discordgo.WebhookEdit) error
+	FollowUp(params *discordgo.WebhookParams) error
+}
+
+// NewInteractionResponder returns a Responder replying through the webhook
+// of interaction.
+func NewInteractionResponder(s *discordgo.Session, interaction *discordgo.Interaction) Responder {
+	return &interactionResponder{session: s, interaction: interaction}
+}
+
+type interactionResponder struct {
+	session     *discordgo.Session
+	interaction *discordgo.Interaction
+}
+
+func (r *interactionResponder) Defer(ephemeral bool) error {
+	resp := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}
+	if ephemeral {
+		resp.Data = &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral}
+	}
+	return r.session.InteractionRespond(r.interaction, resp)
+}
+
+func (r *interactionResponder) Respond(data *discordgo.InteractionResponseData) error {
+	return r.session.InteractionRespond(r.interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+}
+
+func (r *interactionResponder) Edit(edit *discordgo.WebhookEdit) error {
+	_, err := r.session.InteractionResponseEdit(r.interaction, edit)
+	return err
+}
+
+func (r *interactionResponder) FollowUp(params *discordgo.WebhookParams) error {
+	_, err := r.session.FollowupMessageCreate(r.interaction, true, params)
+	return err
+}
@@ -2,75 +2,151 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/kevinfinalboss/Void/api/controllers"
 	"github.com/kevinfinalboss/Void/api/server"
 	"github.com/kevinfinalboss/Void/config"
 	"github.com/kevinfinalboss/Void/internal/bot"
+	"github.com/kevinfinalboss/Void/internal/cluster"
 	"github.com/kevinfinalboss/Void/internal/logger"
 )
 
 func main() {
+	defaultConfig := os.Getenv("VOID_CONFIG")
+	if defaultConfig == "" {
+		defaultConfig = "config.yaml"
+	}
+
+	configPath := flag.String("config", defaultConfig, "path of the configuration file")
+	clusterRole := flag.String("cluster-role", "", "run as a cluster \"coordinator\" or \"worker\"")
+	workerID := flag.String("worker-id", "", "ID of this worker in the cluster")
+	flag.Parse()
+
 	mainCtx, mainCancel := context.WithCancel(context.Background())
 	defer mainCancel()
 
-	cfg, err := config.Load("config.yaml")
+	store, err := config.NewStore(*configPath, func(cfg *config.Config) {
+		if *clusterRole != "" {
+			cfg.Cluster.Enabled = true
+			cfg.Cluster.Role = *clusterRole
+		}
+		if *workerID != "" {
+			cfg.Cluster.WorkerID = *workerID
+		}
+	})
 	if err != nil {
 		log.Fatal("Error loading config:", err)
 	}
+	cfg := store.Get()
 
-	logger := logger.New(cfg.Logger)
+	logger := logger.New(cfg)
 	if logger == nil {
 		log.Fatal("Failed to initialize logger")
 	}
 
+	logDisabled := func(cfg *config.Config) {
+		for feature, problems := range cfg.Disabled() {
+			logger.Warn("Feature disabled", "feature", feature, "problems", strings.Join(problems, "; "))
+		}
+	}
+	logDisabled(cfg)
+
+	store.Subscribe(func(old, cfg *config.Config) {
+		logger.ApplyConfig(old, cfg)
+		logDisabled(cfg)
+	})
+
 	var wg sync.WaitGroup
 	errChan := make(chan error, 2)
 	shutdownChan := make(chan struct{})
 
-	srv := server.NewServer(cfg)
-	if srv == nil {
-		logger.Fatal("Failed to create server")
+	// start and stop run whatever this process is: a standalone bot, a
+	// cluster coordinator or a cluster worker. Workers skip the HTTP server
+	// so several of them can run on one machine.
+	var (
+		start     func() error
+		stop      func() error
+		shards    controllers.ShardReporter
+		serveHTTP = true
+	)
+
+	switch {
+	case cfg.Cluster.Enabled && cfg.Cluster.Role == "coordinator":
+		coordinator := cluster.NewCoordinator(cfg, logger)
+		start, stop, shards = coordinator.Start, coordinator.Stop, coordinator
+
+	case cfg.Cluster.Enabled && cfg.Cluster.Role == "worker":
+		discordBot, err := bot.New(cfg, logger)
+		if err != nil {
+			logger.Fatal("Failed to create bot", "error", err)
+		}
+		store.Subscribe(discordBot.ApplyConfig)
+		worker := cluster.NewWorker(cfg, logger, discordBot)
+		start = worker.Start
+		stop = func() error {
+			// Keep heartbeating while commands drain, so the coordinator
+			// does not hand the shards to another worker meanwhile.
+			err := discordBot.Stop()
+			worker.Stop()
+			return err
+		}
+		serveHTTP = false
+
+	case cfg.Cluster.Enabled:
+		logger.Fatal("Unknown cluster role", "role", cfg.Cluster.Role)
+
+	default:
+		discordBot, err := bot.New(cfg, logger)
+		if err != nil {
+			logger.Fatal("Failed to create bot", "error", err)
+		}
+		store.Subscribe(discordBot.ApplyConfig)
+		start, stop, shards = discordBot.Start, discordBot.Stop, discordBot
 	}
 
-	srv.SetupRoutes()
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		logger.Info("Starting HTTP server...")
+	if serveHTTP {
+		srv := server.NewServer(store)
+		if srv == nil {
+			logger.Fatal("Failed to create server")
+		}
 
+		srv.SetupRoutes(shards)
+		wg.Add(1)
 		go func() {
-			select {
-			case <-mainCtx.Done():
-				shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-				defer cancel()
-				if err := srv.Shutdown(shutdownCtx); err != nil {
-					logger.Error("Server shutdown error: " + err.Error())
-				}
-			case <-shutdownChan:
-				shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-				defer cancel()
-				if err := srv.Shutdown(shutdownCtx); err != nil {
-					logger.Error("Server shutdown error: " + err.Error())
+			defer wg.Done()
+			logger.Info("Starting HTTP server...")
+
+			go func() {
+				select {
+				case <-mainCtx.Done():
+					shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+					defer cancel()
+					if err := srv.Shutdown(shutdownCtx); err != nil {
+						logger.Error("Server shutdown error", "error", err)
+					}
+				case <-shutdownChan:
+					shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+					defer cancel()
+					if err := srv.Shutdown(shutdownCtx); err != nil {
+						logger.Error("Server shutdown error", "error", err)
+					}
 				}
+			}()
+
+			if err := srv.Start(); err != nil && err != http.ErrServerClosed {
+				errChan <- err
+				mainCancel()
 			}
 		}()
-
-		if err := srv.Start(); err != nil && err != http.ErrServerClosed {
-			errChan <- err
-			mainCancel()
-		}
-	}()
-
-	discordBot, err := bot.New(cfg, logger)
-	if err != nil {
-		logger.Fatal("Failed to create bot: " + err.Error())
 	}
 
 	wg.Add(1)
@@ -89,33 +165,57 @@ func main() {
 			}
 		}()
 
-		if err := discordBot.Start(); err != nil {
+		if err := start(); err != nil {
 			errChan <- err
 			mainCancel()
 			return
 		}
 	}()
 
+	// Reload the configuration when its file changes or on SIGHUP. Invalid
+	// configurations are rejected and the current one is kept.
+	reported := func(err error) {
+		if err != nil {
+			logger.Error("Config reload rejected", "error", err)
+			return
+		}
+		logger.Info("Config reloaded")
+	}
+	go store.Watch(shutdownChan, reported)
+
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-hupChan:
+				reported(store.Reload())
+			case <-shutdownChan:
+				return
+			}
+		}
+	}()
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 
 	select {
 	case sig := <-sigChan:
-		logger.Info("Received signal: " + sig.String())
+		logger.Info("Received signal", "signal", sig.String())
 	case err := <-errChan:
-		logger.Error("Error during execution: " + err.Error())
+		logger.Error("Error during execution", "error", err)
 	}
 
 	logger.Info("Initiating shutdown sequence...")
 	close(shutdownChan)
 
-	botShutdownCtx, botShutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	botShutdownCtx, botShutdownCancel := context.WithTimeout(context.Background(), cfg.Discord.Commands.DrainTimeout+30*time.Second)
 	defer botShutdownCancel()
 
 	botDone := make(chan struct{})
 	go func() {
-		if err := discordBot.Stop(); err != nil {
-			logger.Error("Bot shutdown error: " + err.Error())
+		if err := stop(); err != nil {
+			logger.Error("Bot shutdown error", "error", err)
 		}
 		close(botDone)
 	}()